@@ -0,0 +1,214 @@
+// Package apexdoc extracts API documentation from Apex source and renders it
+// as Markdown or HTML.
+package apexdoc
+
+import (
+	"io"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Type is a documented class, interface or enum.
+type Type struct {
+	Kind         string    `json:"kind"`
+	Name         string    `json:"name"`
+	Signature    string    `json:"signature"`
+	Access       string    `json:"access"`
+	Line         int       `json:"line"`
+	Comment      *Comment  `json:"comment,omitempty"`
+	Values       []string  `json:"values,omitempty"`
+	Fields       []*Member `json:"fields,omitempty"`
+	Properties   []*Member `json:"properties,omitempty"`
+	Constructors []*Member `json:"constructors,omitempty"`
+	Methods      []*Member `json:"methods,omitempty"`
+	Types        []*Type   `json:"types,omitempty"`
+}
+
+// Member is a documented field, property, constructor or method.
+type Member struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Signature string   `json:"signature"`
+	Access    string   `json:"access"`
+	Line      int      `json:"line"`
+	Comment   *Comment `json:"comment,omitempty"`
+}
+
+// Parse reads an Apex class, interface or enum and returns its documentation.
+// Triggers have no API and return nil.
+func Parse(filename string, reader io.Reader) (*Type, error) {
+	tree, stream, err := formatter.Parse(filename, reader)
+	if err != nil {
+		return nil, err
+	}
+	decl := tree.TypeDeclaration()
+	if decl == nil {
+		return nil, nil
+	}
+	e := &extractor{tokens: stream}
+	return e.typeDeclaration(decl, decl.AllModifier(), decl.ClassDeclaration(), decl.InterfaceDeclaration(), decl.EnumDeclaration()), nil
+}
+
+type extractor struct {
+	tokens *antlr.CommonTokenStream
+}
+
+// comment returns the ApexDoc comment closest before ctx, if any.
+func (e *extractor) comment(ctx antlr.ParserRuleContext) *Comment {
+	comments := e.tokens.GetHiddenTokensToLeft(ctx.GetStart().GetTokenIndex(), formatter.COMMENTS_CHANNEL)
+	for i := len(comments) - 1; i >= 0; i-- {
+		if formatter.IsDocComment(comments[i].GetText()) {
+			return ParseComment(comments[i].GetText())
+		}
+	}
+	return nil
+}
+
+func (e *extractor) typeDeclaration(ctx antlr.ParserRuleContext, modifiers []parser.IModifierContext, class parser.IClassDeclarationContext, iface parser.IInterfaceDeclarationContext, enum parser.IEnumDeclarationContext) *Type {
+	t := &Type{
+		Access:  access(modifiers, "private"),
+		Line:    ctx.GetStart().GetLine(),
+		Comment: e.comment(ctx),
+	}
+	mods := modifierText(modifiers)
+	switch {
+	case class != nil:
+		t.Kind = "class"
+		t.Name = class.Id().GetText()
+		t.Signature = mods + "class " + t.Name
+		if class.EXTENDS() != nil {
+			t.Signature += " extends " + typeText(class.TypeRef())
+		}
+		if class.IMPLEMENTS() != nil {
+			t.Signature += " implements " + typeText(class.TypeList())
+		}
+		for _, d := range class.ClassBody().AllClassBodyDeclaration() {
+			e.classBodyDeclaration(t, d)
+		}
+	case iface != nil:
+		t.Kind = "interface"
+		t.Name = iface.Id().GetText()
+		t.Signature = mods + "interface " + t.Name
+		if iface.EXTENDS() != nil {
+			t.Signature += " extends " + typeText(iface.TypeList())
+		}
+		for _, m := range iface.InterfaceBody().AllInterfaceMethodDeclaration() {
+			t.Methods = append(t.Methods, &Member{
+				Kind:      "method",
+				Name:      m.Id().GetText(),
+				Signature: modifierText(m.AllModifier()) + returnType(m.TypeRef()) + " " + m.Id().GetText() + parameters(m.FormalParameters()),
+				Access:    "public",
+				Line:      m.GetStart().GetLine(),
+				Comment:   e.comment(m),
+			})
+		}
+	case enum != nil:
+		t.Kind = "enum"
+		t.Name = enum.Id().GetText()
+		t.Signature = mods + "enum " + t.Name
+		if constants := enum.EnumConstants(); constants != nil {
+			for _, id := range constants.AllId() {
+				t.Values = append(t.Values, id.GetText())
+			}
+		}
+	}
+	return t
+}
+
+func (e *extractor) classBodyDeclaration(t *Type, ctx parser.IClassBodyDeclarationContext) {
+	member := ctx.MemberDeclaration()
+	if member == nil {
+		return
+	}
+	modifiers := ctx.AllModifier()
+	mods := modifierText(modifiers)
+	newMember := func(kind, name, signature string) *Member {
+		return &Member{
+			Kind:      kind,
+			Name:      name,
+			Signature: signature,
+			Access:    access(modifiers, "private"),
+			Line:      ctx.GetStart().GetLine(),
+			Comment:   e.comment(ctx),
+		}
+	}
+	switch {
+	case member.ClassDeclaration() != nil || member.InterfaceDeclaration() != nil || member.EnumDeclaration() != nil:
+		t.Types = append(t.Types, e.typeDeclaration(ctx, modifiers, member.ClassDeclaration(), member.InterfaceDeclaration(), member.EnumDeclaration()))
+	case member.FieldDeclaration() != nil:
+		f := member.FieldDeclaration()
+		for _, v := range f.VariableDeclarators().AllVariableDeclarator() {
+			name := v.Id().GetText()
+			t.Fields = append(t.Fields, newMember("field", name, mods+typeText(f.TypeRef())+" "+name))
+		}
+	case member.PropertyDeclaration() != nil:
+		p := member.PropertyDeclaration()
+		name := p.Id().GetText()
+		t.Properties = append(t.Properties, newMember("property", name, mods+typeText(p.TypeRef())+" "+name))
+	case member.ConstructorDeclaration() != nil:
+		c := member.ConstructorDeclaration()
+		name := c.QualifiedName().GetText()
+		t.Constructors = append(t.Constructors, newMember("constructor", name, mods+name+parameters(c.FormalParameters())))
+	case member.MethodDeclaration() != nil:
+		m := member.MethodDeclaration()
+		name := m.Id().GetText()
+		t.Methods = append(t.Methods, newMember("method", name, mods+returnType(m.TypeRef())+" "+name+parameters(m.FormalParameters())))
+	}
+}
+
+// access returns the access modifier in modifiers, or def if there is none.
+func access(modifiers []parser.IModifierContext, def string) string {
+	if a := formatter.AccessModifier(modifiers); a != "" {
+		return a
+	}
+	return def
+}
+
+// modifierText returns modifiers, including annotations, followed by a
+// space.
+func modifierText(modifiers []parser.IModifierContext) string {
+	var b strings.Builder
+	for _, m := range modifiers {
+		if a := m.Annotation(); a != nil {
+			b.WriteString(a.GetText() + " ")
+			continue
+		}
+		words := []string{}
+		for _, w := range m.GetChildren() {
+			words = append(words, w.(antlr.TerminalNode).GetText())
+		}
+		b.WriteString(strings.Join(words, " ") + " ")
+	}
+	return b.String()
+}
+
+func typeText(ctx antlr.ParserRuleContext) string {
+	return strings.ReplaceAll(ctx.GetText(), ",", ", ")
+}
+
+func returnType(ctx parser.ITypeRefContext) string {
+	if ctx == nil {
+		return "void"
+	}
+	return typeText(ctx)
+}
+
+func parameters(ctx parser.IFormalParametersContext) string {
+	params := []string{}
+	if list := ctx.FormalParameterList(); list != nil {
+		for _, p := range list.AllFormalParameter() {
+			params = append(params, modifierText(p.AllModifier())+typeText(p.TypeRef())+" "+p.Id().GetText())
+		}
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+// Visible reports whether a declaration with the given access belongs in
+// the documentation.  Private declarations are only included if
+// includePrivate is set.
+func Visible(access string, includePrivate bool) bool {
+	return includePrivate || access != "private"
+}
@@ -0,0 +1,114 @@
+package apexdoc
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseComment(t *testing.T) {
+	tests := []struct {
+		input  string
+		output *Comment
+	}{
+		{
+			"/** Adds numbers. */",
+			&Comment{Description: "Adds numbers."},
+		},
+		{
+			`/**
+	 * Adds
+	 *   numbers.
+	 *
+	 * Second paragraph.
+	 * @param a first
+	 *        number
+	 * @param b
+	 * @returns the sum
+	 * @throws MathException on overflow
+	 * @author Jane
+	 * @example
+	 * @IsTest
+	 * static void adds() {
+	 *     Foo.add(1, 2);
+	 * }
+	 */`,
+			&Comment{
+				Description: "Adds numbers.\n\nSecond paragraph.",
+				Params:      []Param{{Name: "a", Description: "first number"}, {Name: "b"}},
+				Return:      "the sum",
+				Throws:      []Param{{Name: "MathException", Description: "on overflow"}},
+				Example:     "@IsTest\nstatic void adds() {\n    Foo.add(1, 2);\n}",
+				Tags:        []Tag{{Name: "author", Text: "Jane"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		out := ParseComment(tt.input)
+		if !reflect.DeepEqual(out, tt.output) {
+			t.Errorf("unexpected comment.  expected:\n%#v\ngot:\n%#v\n", tt.output, out)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `/**
+ * Does things.  More detail.
+ */
+public with sharing class Foo {
+	/** The name */
+	public static final String NAME = 'foo';
+	private Integer hidden;
+	public Map<Id, String> labels { get; set; }
+	/** @param values values to add */
+	public Integer add(List<Integer> values) { return 0; }
+	private void helper() {}
+	public enum Color { RED, GREEN }
+}`
+	foo, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if foo.Signature != "public with sharing class Foo" || foo.Comment.Summary() != "Does things." {
+		t.Errorf("unexpected type: %#v", foo)
+	}
+	filtered := foo.Filter(false)
+	signatures := []string{}
+	for _, members := range [][]*Member{filtered.Fields, filtered.Properties, filtered.Methods} {
+		for _, m := range members {
+			signatures = append(signatures, m.Signature)
+		}
+	}
+	expected := []string{
+		"public static final String NAME",
+		"public Map<Id, String> labels",
+		"public Integer add(List<Integer> values)",
+	}
+	if !reflect.DeepEqual(signatures, expected) {
+		t.Errorf("unexpected members.  expected:\n%q\ngot:\n%q\n", expected, signatures)
+	}
+	if len(foo.Filter(true).Methods) != 2 {
+		t.Errorf("expected private methods to be included")
+	}
+
+	pages := Pages(filtered)
+	if len(pages) != 2 || pages[1].Name != "Foo.Color" || !reflect.DeepEqual(pages[1].Type.Values, []string{"RED", "GREEN"}) {
+		t.Errorf("unexpected pages: %#v", pages)
+	}
+	var md bytes.Buffer
+	if err := WritePage(&md, "markdown", pages[0]); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, s := range []string{"# Foo\n", "### add\n", "| `values` | values to add |", "- [Color](Foo.Color.md)"} {
+		if !strings.Contains(md.String(), s) {
+			t.Errorf("expected markdown to contain %q:\n%s", s, md.String())
+		}
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	if _, err := Parse("", strings.NewReader("public class Foo {")); err == nil {
+		t.Errorf("expected syntax error")
+	}
+}
@@ -0,0 +1,86 @@
+package apexdoc
+
+import (
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/formatter"
+)
+
+// Comment is a parsed ApexDoc comment.
+type Comment struct {
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params,omitempty"`
+	Return      string  `json:"return,omitempty"`
+	Throws      []Param `json:"throws,omitempty"`
+	Example     string  `json:"example,omitempty"`
+	Tags        []Tag   `json:"tags,omitempty"`
+}
+
+// Param documents a parameter or, for @throws, an exception type.
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag is a tag without special handling, such as @author or @see.
+type Tag struct {
+	Name string `json:"name"`
+	Text string `json:"text,omitempty"`
+}
+
+// ParseComment parses the text of an ApexDoc comment, including the
+// surrounding /** and */.
+func ParseComment(text string) *Comment {
+	doc := formatter.ParseDocComment(text)
+	c := &Comment{}
+	description := doc.Description
+	for _, t := range doc.Tags {
+		switch t.Name {
+		case "@description":
+			description = append(description, t.Lines...)
+		case "@param":
+			name, desc := t.Param()
+			c.Params = append(c.Params, Param{Name: name, Description: desc})
+		case "@return", "@returns":
+			c.Return = t.Text()
+		case "@throws", "@exception":
+			name, desc := t.Param()
+			c.Throws = append(c.Throws, Param{Name: name, Description: desc})
+		case "@example":
+			c.Example = strings.Join(t.Verbatim(), "\n")
+		default:
+			c.Tags = append(c.Tags, Tag{Name: strings.TrimPrefix(t.Name, "@"), Text: t.Text()})
+		}
+	}
+	c.Description = joinParagraphs(description)
+	return c
+}
+
+// Summary returns the first sentence of the description.
+func (c *Comment) Summary() string {
+	if c == nil {
+		return ""
+	}
+	d := strings.SplitN(c.Description, "\n\n", 2)[0]
+	if i := strings.Index(d, ". "); i >= 0 {
+		return d[:i+1]
+	}
+	return d
+}
+
+// joinParagraphs joins lines into paragraphs separated by blank lines.
+func joinParagraphs(lines []string) string {
+	paragraphs := []string{}
+	paragraph := []string{}
+	for _, line := range append(lines, "") {
+		if strings.TrimSpace(line) != "" {
+			paragraph = append(paragraph, line)
+			continue
+		}
+		if len(paragraph) > 0 {
+			paragraphs = append(paragraphs, strings.Join(strings.Fields(strings.Join(paragraph, " ")), " "))
+			paragraph = nil
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
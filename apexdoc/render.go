@@ -0,0 +1,248 @@
+package apexdoc
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{"markdown", "html"}
+
+// Extension returns the file extension for pages in format.
+func Extension(format string) string {
+	if format == "html" {
+		return ".html"
+	}
+	return ".md"
+}
+
+// Page is a documentation page for a single type.  Inner types get their
+// own pages, named after the outer type, e.g. Foo.Inner.
+type Page struct {
+	Name string
+	Type *Type
+}
+
+// Pages returns a page for t and for each of its inner types.
+func Pages(t *Type) []Page {
+	return pages("", t)
+}
+
+func pages(outer string, t *Type) []Page {
+	name := t.Name
+	if outer != "" {
+		name = outer + "." + t.Name
+	}
+	result := []Page{{Name: name, Type: t}}
+	for _, inner := range t.Types {
+		result = append(result, pages(name, inner)...)
+	}
+	return result
+}
+
+// Filter returns a copy of t without declarations that are not Visible.
+func (t *Type) Filter(includePrivate bool) *Type {
+	filtered := *t
+	filterMembers := func(members []*Member) []*Member {
+		var visible []*Member
+		for _, m := range members {
+			if Visible(m.Access, includePrivate) {
+				visible = append(visible, m)
+			}
+		}
+		return visible
+	}
+	filtered.Fields = filterMembers(t.Fields)
+	filtered.Properties = filterMembers(t.Properties)
+	filtered.Constructors = filterMembers(t.Constructors)
+	filtered.Methods = filterMembers(t.Methods)
+	filtered.Types = nil
+	for _, inner := range t.Types {
+		if Visible(inner.Access, includePrivate) {
+			filtered.Types = append(filtered.Types, inner.Filter(includePrivate))
+		}
+	}
+	return &filtered
+}
+
+// WritePage writes the documentation page for p in format.
+func WritePage(w io.Writer, format string, p Page) error {
+	if format == "html" {
+		return htmlPage.Execute(w, p)
+	}
+	return markdownPage.Execute(w, p)
+}
+
+// WriteIndex writes an index linking to each page in format.
+func WriteIndex(w io.Writer, format string, pages []Page) error {
+	if format == "html" {
+		return htmlIndex.Execute(w, pages)
+	}
+	return markdownIndex.Execute(w, pages)
+}
+
+func paragraphs(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n\n")
+}
+
+type section struct {
+	Title   string
+	Members []*Member
+}
+
+var funcs = map[string]interface{}{
+	"paragraphs": paragraphs,
+	"section": func(title string, members []*Member) section {
+		return section{Title: title, Members: members}
+	},
+	"cell": func(s string) string {
+		return strings.ReplaceAll(s, "|", `\|`)
+	},
+}
+
+var markdownPage = template.Must(template.New("page").Funcs(funcs).Parse(`# {{ .Name }}
+
+` + "`{{ .Type.Signature }}`" + `
+{{ with .Type.Comment }}{{ template "comment" . }}{{ end }}
+{{- with .Type.Values }}
+## Values
+{{ range . }}
+- ` + "`{{ . }}`" + `{{ end }}
+{{ end }}
+{{- template "members" (section "Fields" .Type.Fields) }}
+{{- template "members" (section "Properties" .Type.Properties) }}
+{{- template "members" (section "Constructors" .Type.Constructors) }}
+{{- template "members" (section "Methods" .Type.Methods) }}
+{{- if .Type.Types }}
+## Inner Types
+{{ $outer := .Name }}{{ range .Type.Types }}
+- [{{ .Name }}]({{ $outer }}.{{ .Name }}.md){{ with .Comment }}{{ with .Summary }}: {{ . }}{{ end }}{{ end }}{{ end }}
+{{ end }}
+{{- define "members" }}{{ if .Members }}
+## {{ .Title }}
+{{ range .Members }}
+### {{ .Name }}
+
+` + "`{{ .Signature }}`" + `
+{{ with .Comment }}{{ template "comment" . }}{{ end }}{{ end }}{{ end }}{{ end }}
+{{- define "comment" }}{{ range paragraphs .Description }}
+{{ . }}
+{{ end }}
+{{- with .Params }}
+**Parameters**
+
+| Name | Description |
+| --- | --- |
+{{ range . }}| ` + "`{{ .Name }}`" + ` | {{ cell .Description }} |
+{{ end }}{{ end }}
+{{- with .Return }}
+**Returns**
+
+{{ . }}
+{{ end }}
+{{- with .Throws }}
+**Throws**
+
+| Exception | Description |
+| --- | --- |
+{{ range . }}| ` + "`{{ .Name }}`" + ` | {{ cell .Description }} |
+{{ end }}{{ end }}
+{{- range .Tags }}
+**{{ .Name }}:** {{ .Text }}
+{{ end }}
+{{- with .Example }}
+**Example**
+
+` + "```apex" + `
+{{ . }}
+` + "```" + `
+{{ end }}{{ end }}`))
+
+var markdownIndex = template.Must(template.New("index").Funcs(funcs).Parse(`# API Reference
+{{ range . }}
+- [{{ .Name }}]({{ .Name }}.md){{ with .Type.Comment }}{{ with .Summary }}: {{ . }}{{ end }}{{ end }}{{ end }}
+`))
+
+var htmlPage = htmltemplate.Must(htmltemplate.New("page").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Name }}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: auto; padding: 1em; }
+code, pre { background: #f4f4f4; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+</style>
+</head>
+<body>
+<p><a href="index.html">Index</a></p>
+<h1>{{ .Name }}</h1>
+<p><code>{{ .Type.Signature }}</code></p>
+{{ with .Type.Comment }}{{ template "comment" . }}{{ end }}
+{{- with .Type.Values }}
+<h2>Values</h2>
+<ul>
+{{ range . }}<li><code>{{ . }}</code></li>
+{{ end }}</ul>
+{{ end }}
+{{- template "members" (section "Fields" .Type.Fields) }}
+{{- template "members" (section "Properties" .Type.Properties) }}
+{{- template "members" (section "Constructors" .Type.Constructors) }}
+{{- template "members" (section "Methods" .Type.Methods) }}
+{{- if .Type.Types }}
+<h2>Inner Types</h2>
+<ul>
+{{ $outer := .Name }}{{ range .Type.Types }}<li><a href="{{ $outer }}.{{ .Name }}.html">{{ .Name }}</a>{{ with .Comment }}{{ with .Summary }}: {{ . }}{{ end }}{{ end }}</li>
+{{ end }}</ul>
+{{ end -}}
+</body>
+</html>
+{{ define "members" }}{{ if .Members }}
+<h2>{{ .Title }}</h2>
+{{ range .Members }}
+<h3>{{ .Name }}</h3>
+<p><code>{{ .Signature }}</code></p>
+{{ with .Comment }}{{ template "comment" . }}{{ end }}{{ end }}{{ end }}{{ end }}
+{{- define "comment" }}{{ range paragraphs .Description }}<p>{{ . }}</p>
+{{ end }}
+{{- with .Params }}<h4>Parameters</h4>
+<table>
+<tr><th>Name</th><th>Description</th></tr>
+{{ range . }}<tr><td><code>{{ .Name }}</code></td><td>{{ .Description }}</td></tr>
+{{ end }}</table>
+{{ end }}
+{{- with .Return }}<h4>Returns</h4>
+<p>{{ . }}</p>
+{{ end }}
+{{- with .Throws }}<h4>Throws</h4>
+<table>
+<tr><th>Exception</th><th>Description</th></tr>
+{{ range . }}<tr><td><code>{{ .Name }}</code></td><td>{{ .Description }}</td></tr>
+{{ end }}</table>
+{{ end }}
+{{- range .Tags }}<p><strong>{{ .Name }}:</strong> {{ .Text }}</p>
+{{ end }}
+{{- with .Example }}<h4>Example</h4>
+<pre><code>{{ . }}</code></pre>
+{{ end }}{{ end }}`))
+
+var htmlIndex = htmltemplate.Must(htmltemplate.New("index").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API Reference</title>
+</head>
+<body>
+<h1>API Reference</h1>
+<ul>
+{{ range . }}<li><a href="{{ .Name }}.html">{{ .Name }}</a>{{ with .Type.Comment }}{{ with .Summary }}: {{ . }}{{ end }}{{ end }}</li>
+{{ end }}</ul>
+</body>
+</html>
+`))
@@ -0,0 +1,561 @@
+// Package ast declares typed syntax trees for Apex and converts parse trees
+// produced by the parser package into them.
+//
+// Every node records its source span.  Declarations and statements also
+// record the comments that precede them.
+package ast
+
+// Pos is a position in source.  Line and Column are 1-based.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+// Span is the source range of a node.  End is the position just past the
+// last character.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// Pos returns the start of the node.
+func (s Span) Pos() Pos { return s.Start }
+
+// EndPos returns the position just past the end of the node.
+func (s Span) EndPos() Pos { return s.End }
+
+// Node is implemented by all syntax tree nodes.
+type Node interface {
+	Pos() Pos
+	EndPos() Pos
+}
+
+// Comment is a line or block comment, including its delimiters.
+type Comment struct {
+	Span
+	Text string
+}
+
+// Annotation is an annotation such as @AuraEnabled(cacheable=true).  Args is
+// the source text between the parentheses.
+type Annotation struct {
+	Span
+	Name string
+	Args string
+}
+
+// Modifiers are the annotations and keywords preceding a declaration.
+// Keywords are lower case, e.g. "public", "with sharing".
+type Modifiers struct {
+	Annotations []*Annotation
+	Keywords    []string
+}
+
+// Has reports whether keyword is among the modifiers.
+func (m Modifiers) Has(keyword string) bool {
+	for _, k := range m.Keywords {
+		if k == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeRef is a type name such as Map<Id, List<Account>> or String[].
+type TypeRef struct {
+	Span
+	Name string
+}
+
+// ----------------------------------------------------------------------------
+// Declarations
+
+// Decl is implemented by declarations.
+type Decl interface {
+	Node
+	declNode()
+}
+
+// File is a compilation unit: a single top-level type or a trigger.
+type File struct {
+	Span
+	Decl Decl
+}
+
+type ClassDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Name       string
+	Extends    *TypeRef
+	Implements []*TypeRef
+	Members    []Decl
+}
+
+type InterfaceDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Name    string
+	Extends []*TypeRef
+	Methods []*MethodDecl
+}
+
+type EnumDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Name   string
+	Values []*Ident
+}
+
+type TriggerDecl struct {
+	Span
+	Comments []*Comment
+	Name     string
+	Object   string
+	// Events are lower case, e.g. "before insert".
+	Events []string
+	Body   []Stmt
+}
+
+// FieldDecl declares one or more fields of the same type.
+type FieldDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Type *TypeRef
+	Vars []*VarDecl
+}
+
+type PropertyDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Type   *TypeRef
+	Name   string
+	Getter *Accessor
+	Setter *Accessor
+}
+
+// Accessor is a property getter or setter.  Body is nil for automatic
+// accessors.
+type Accessor struct {
+	Span
+	Modifiers
+	Body *Block
+}
+
+type ConstructorDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Name   string
+	Params []*Param
+	Body   *Block
+}
+
+// MethodDecl is a method.  ReturnType is nil for void methods and Body is
+// nil for abstract and interface methods.
+type MethodDecl struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	ReturnType *TypeRef
+	Name       string
+	Params     []*Param
+	Body       *Block
+}
+
+// InitializerDecl is a static or instance initializer block.
+type InitializerDecl struct {
+	Span
+	Comments []*Comment
+	Static   bool
+	Body     *Block
+}
+
+type Param struct {
+	Span
+	Modifiers
+	Type *TypeRef
+	Name string
+}
+
+// VarDecl declares a single variable.  Init is nil if there is no
+// initializer.
+type VarDecl struct {
+	Span
+	Name string
+	Init Expr
+}
+
+func (*File) declNode()            {}
+func (*ClassDecl) declNode()       {}
+func (*InterfaceDecl) declNode()   {}
+func (*EnumDecl) declNode()        {}
+func (*TriggerDecl) declNode()     {}
+func (*FieldDecl) declNode()       {}
+func (*PropertyDecl) declNode()    {}
+func (*ConstructorDecl) declNode() {}
+func (*MethodDecl) declNode()      {}
+func (*InitializerDecl) declNode() {}
+
+// ----------------------------------------------------------------------------
+// Statements
+
+// Stmt is implemented by statements.
+type Stmt interface {
+	Node
+	stmtNode()
+}
+
+type Block struct {
+	Span
+	Comments []*Comment
+	Stmts    []Stmt
+}
+
+type LocalVarStmt struct {
+	Span
+	Comments []*Comment
+	Modifiers
+	Type *TypeRef
+	Vars []*VarDecl
+}
+
+type ExprStmt struct {
+	Span
+	Comments []*Comment
+	X        Expr
+}
+
+// IfStmt is an if statement.  Else is nil if there is no else branch.
+type IfStmt struct {
+	Span
+	Comments []*Comment
+	Cond     Expr
+	Then     Stmt
+	Else     Stmt
+}
+
+type SwitchStmt struct {
+	Span
+	Comments []*Comment
+	X        Expr
+	Whens    []*WhenClause
+}
+
+// WhenClause is a branch of a switch statement.  It matches either the
+// literal or enum Values, a type (when Account a), or anything (when else).
+type WhenClause struct {
+	Span
+	Else   bool
+	Values []string
+	Type   string
+	Var    string
+	Body   *Block
+}
+
+// ForStmt is a traditional for loop.  Init is either a *LocalVarStmt or an
+// *ExprListStmt; Init, Cond and Update may be nil.  Body is nil if the loop
+// has an empty body, `;`.
+type ForStmt struct {
+	Span
+	Comments []*Comment
+	Init     Stmt
+	Cond     Expr
+	Update   []Expr
+	Body     Stmt
+}
+
+// ExprListStmt is a comma-separated list of expressions used in a for
+// loop's initializer.
+type ExprListStmt struct {
+	Span
+	List []Expr
+}
+
+// ForEachStmt is an enhanced for loop, for (Type Var : X).  Body is nil if
+// the loop has an empty body.
+type ForEachStmt struct {
+	Span
+	Comments []*Comment
+	Type     *TypeRef
+	Var      string
+	X        Expr
+	Body     Stmt
+}
+
+// WhileStmt is a while loop.  Body is nil if the loop has an empty body.
+type WhileStmt struct {
+	Span
+	Comments []*Comment
+	Cond     Expr
+	Body     Stmt
+}
+
+type DoWhileStmt struct {
+	Span
+	Comments []*Comment
+	Body     Stmt
+	Cond     Expr
+}
+
+type TryStmt struct {
+	Span
+	Comments []*Comment
+	Body     *Block
+	Catches  []*CatchClause
+	Finally  *Block
+}
+
+type CatchClause struct {
+	Span
+	Modifiers
+	Type string
+	Name string
+	Body *Block
+}
+
+// ReturnStmt is a return statement.  X is nil for a bare return.
+type ReturnStmt struct {
+	Span
+	Comments []*Comment
+	X        Expr
+}
+
+type ThrowStmt struct {
+	Span
+	Comments []*Comment
+	X        Expr
+}
+
+type BreakStmt struct {
+	Span
+	Comments []*Comment
+}
+
+type ContinueStmt struct {
+	Span
+	Comments []*Comment
+}
+
+// DMLStmt is an insert, update, upsert, delete, undelete or merge statement.
+// ExternalID is the upsert external id field, and Y the record merged into X.
+type DMLStmt struct {
+	Span
+	Comments   []*Comment
+	Op         string
+	X          Expr
+	Y          Expr
+	ExternalID string
+}
+
+type RunAsStmt struct {
+	Span
+	Comments []*Comment
+	Args     []Expr
+	Body     *Block
+}
+
+// DeclStmt is a declaration inside a block, such as a class declared in a
+// trigger.
+type DeclStmt struct {
+	Span
+	Decl Decl
+}
+
+func (*Block) stmtNode()        {}
+func (*LocalVarStmt) stmtNode() {}
+func (*ExprStmt) stmtNode()     {}
+func (*IfStmt) stmtNode()       {}
+func (*SwitchStmt) stmtNode()   {}
+func (*ForStmt) stmtNode()      {}
+func (*ExprListStmt) stmtNode() {}
+func (*ForEachStmt) stmtNode()  {}
+func (*WhileStmt) stmtNode()    {}
+func (*DoWhileStmt) stmtNode()  {}
+func (*TryStmt) stmtNode()      {}
+func (*ReturnStmt) stmtNode()   {}
+func (*ThrowStmt) stmtNode()    {}
+func (*BreakStmt) stmtNode()    {}
+func (*ContinueStmt) stmtNode() {}
+func (*DMLStmt) stmtNode()      {}
+func (*RunAsStmt) stmtNode()    {}
+func (*DeclStmt) stmtNode()     {}
+
+// ----------------------------------------------------------------------------
+// Expressions
+
+// Expr is implemented by expressions.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+type Ident struct {
+	Span
+	Name string
+}
+
+// Literal kinds.
+const (
+	IntegerLit = "integer"
+	LongLit    = "long"
+	NumberLit  = "number"
+	StringLit  = "string"
+	BooleanLit = "boolean"
+	NullLit    = "null"
+)
+
+// BasicLit is a literal.  Value is the source text, including quotes for
+// strings.
+type BasicLit struct {
+	Span
+	Kind  string
+	Value string
+}
+
+type ThisExpr struct {
+	Span
+}
+
+type SuperExpr struct {
+	Span
+}
+
+// ClassLit is a type literal such as Account.class.
+type ClassLit struct {
+	Span
+	Type *TypeRef
+}
+
+// SelectorExpr is a field access, X.Name or X?.Name.
+type SelectorExpr struct {
+	Span
+	X       Expr
+	Name    string
+	SafeNav bool
+}
+
+// CallExpr is a method call.  Recv is nil for unqualified calls, including
+// this(...) and super(...) constructor calls, whose Name is "this" or
+// "super".
+type CallExpr struct {
+	Span
+	Recv    Expr
+	Name    string
+	Args    []Expr
+	SafeNav bool
+}
+
+type IndexExpr struct {
+	Span
+	X     Expr
+	Index Expr
+}
+
+// BinaryExpr is a binary operation such as a + b or a && b.
+type BinaryExpr struct {
+	Span
+	Op string
+	X  Expr
+	Y  Expr
+}
+
+// UnaryExpr is a prefix or postfix operation such as !a, -a or a++.
+type UnaryExpr struct {
+	Span
+	Op      string
+	X       Expr
+	Postfix bool
+}
+
+// AssignExpr is an assignment such as a = b or a += b.
+type AssignExpr struct {
+	Span
+	Op  string
+	Lhs Expr
+	Rhs Expr
+}
+
+type CastExpr struct {
+	Span
+	Type *TypeRef
+	X    Expr
+}
+
+// CondExpr is a ternary expression, Cond ? Then : Else.
+type CondExpr struct {
+	Span
+	Cond Expr
+	Then Expr
+	Else Expr
+}
+
+type InstanceOfExpr struct {
+	Span
+	X    Expr
+	Type *TypeRef
+}
+
+type ParenExpr struct {
+	Span
+	X Expr
+}
+
+// NewExpr creates an object, array or collection.  Args holds constructor
+// arguments, Size the size of a new array, Elems the elements of a list, set
+// or array initializer, and Pairs the entries of a map initializer.
+type NewExpr struct {
+	Span
+	Type  *TypeRef
+	Args  []Expr
+	Size  Expr
+	Elems []Expr
+	Pairs []*MapPair
+}
+
+type MapPair struct {
+	Span
+	Key   Expr
+	Value Expr
+}
+
+// SOQLQuery is an inline SOQL query.  Text is the query as written, without
+// brackets.  Binds are the Apex expressions bound with a colon.
+type SOQLQuery struct {
+	Span
+	Text   string
+	Fields []string
+	From   []string
+	Binds  []Expr
+}
+
+// SOSLQuery is an inline SOSL search.  Text is the search as written,
+// without brackets.
+type SOSLQuery struct {
+	Span
+	Text  string
+	Binds []Expr
+}
+
+func (*Ident) exprNode()          {}
+func (*BasicLit) exprNode()       {}
+func (*ThisExpr) exprNode()       {}
+func (*SuperExpr) exprNode()      {}
+func (*ClassLit) exprNode()       {}
+func (*SelectorExpr) exprNode()   {}
+func (*CallExpr) exprNode()       {}
+func (*IndexExpr) exprNode()      {}
+func (*BinaryExpr) exprNode()     {}
+func (*UnaryExpr) exprNode()      {}
+func (*AssignExpr) exprNode()     {}
+func (*CastExpr) exprNode()       {}
+func (*CondExpr) exprNode()       {}
+func (*InstanceOfExpr) exprNode() {}
+func (*ParenExpr) exprNode()      {}
+func (*NewExpr) exprNode()        {}
+func (*SOQLQuery) exprNode()      {}
+func (*SOSLQuery) exprNode()      {}
@@ -0,0 +1,139 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `public with sharing class Foo {
+	// the count
+	private static Integer count = 0;
+	/** Finds accounts. */
+	@AuraEnabled(cacheable=true)
+	public List<Account> find(String name) {
+		for (Account a : [SELECT Id FROM Account WHERE Name = :name]) {
+			if (a.Id != null && count >= 1) {
+				update a;
+			}
+		}
+		return this.accounts?.get(name);
+	}
+}`
+	f, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	class, ok := f.Decl.(*ClassDecl)
+	if !ok {
+		t.Fatalf("expected *ClassDecl, got %T", f.Decl)
+	}
+	if class.Name != "Foo" || !class.Has("with sharing") || class.Span != (Span{Pos{1, 1}, Pos{14, 2}}) {
+		t.Errorf("unexpected class: %#v", class)
+	}
+	if len(class.Members) != 2 {
+		t.Fatalf("expected 2 members, got %d", len(class.Members))
+	}
+
+	field := class.Members[0].(*FieldDecl)
+	if len(field.Comments) != 1 || field.Comments[0].Text != "// the count" {
+		t.Errorf("unexpected field comments: %#v", field.Comments)
+	}
+	if field.Type.Name != "Integer" || field.Vars[0].Name != "count" || field.Vars[0].Init.(*BasicLit).Kind != IntegerLit {
+		t.Errorf("unexpected field: %#v", field)
+	}
+
+	method := class.Members[1].(*MethodDecl)
+	if len(method.Comments) != 1 || method.Comments[0].Text != "/** Finds accounts. */" {
+		t.Errorf("unexpected method comments: %#v", method.Comments)
+	}
+	if a := method.Annotations; len(a) != 1 || a[0].Name != "AuraEnabled" || a[0].Args != "cacheable=true" {
+		t.Errorf("unexpected annotations: %#v", a)
+	}
+	if method.ReturnType.Name != "List<Account>" || method.Params[0].Type.Name != "String" || method.Params[0].Name != "name" {
+		t.Errorf("unexpected method: %#v", method)
+	}
+
+	loop := method.Body.Stmts[0].(*ForEachStmt)
+	query := loop.X.(*SOQLQuery)
+	if query.Text != "SELECT Id FROM Account WHERE Name = :name" || query.From[0] != "Account" || query.Binds[0].(*Ident).Name != "name" {
+		t.Errorf("unexpected query: %#v", query)
+	}
+	cond := loop.Body.(*Block).Stmts[0].(*IfStmt).Cond.(*BinaryExpr)
+	if cond.Op != "&&" || cond.Y.(*BinaryExpr).Op != ">=" || cond.X.(*BinaryExpr).X.(*SelectorExpr).Name != "Id" {
+		t.Errorf("unexpected condition: %#v", cond)
+	}
+	if cond.Span != (Span{Pos{8, 8}, Pos{8, 34}}) {
+		t.Errorf("unexpected condition span: %#v", cond.Span)
+	}
+
+	call := method.Body.Stmts[1].(*ReturnStmt).X.(*CallExpr)
+	if call.Name != "get" || !call.SafeNav {
+		t.Errorf("unexpected call: %#v", call)
+	}
+	if _, ok := call.Recv.(*SelectorExpr).X.(*ThisExpr); !ok {
+		t.Errorf("unexpected receiver: %#v", call.Recv)
+	}
+
+	var dml []string
+	comments := 0
+	Inspect(f, func(n Node) bool {
+		switch n := n.(type) {
+		case *DMLStmt:
+			dml = append(dml, n.Op)
+		case *Comment:
+			comments++
+		}
+		return true
+	})
+	if len(dml) != 1 || dml[0] != "update" || comments != 2 {
+		t.Errorf("unexpected Inspect results: %v, %d comments", dml, comments)
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	src := `trigger AccountTrigger on Account (before insert, after update) {
+	AccountHandler.run(Trigger.new);
+}`
+	f, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	trigger := f.Decl.(*TriggerDecl)
+	if trigger.Name != "AccountTrigger" || trigger.Object != "Account" || strings.Join(trigger.Events, ",") != "before insert,after update" {
+		t.Errorf("unexpected trigger: %#v", trigger)
+	}
+	if call := trigger.Body[0].(*ExprStmt).X.(*CallExpr); call.Name != "run" || len(call.Args) != 1 {
+		t.Errorf("unexpected call: %#v", call)
+	}
+}
+
+func TestParseEmptyLoopBody(t *testing.T) {
+	src := `public class Foo {
+	void skip(Iterator<String> it, List<String> items) {
+		while (it.hasNext());
+		for (Integer i = 0; i < 10; i++);
+		for (String s : items);
+	}
+}`
+	f, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	stmts := f.Decl.(*ClassDecl).Members[0].(*MethodDecl).Body.Stmts
+	if w := stmts[0].(*WhileStmt); w.Body != nil || w.Cond == nil {
+		t.Errorf("unexpected while: %#v", w)
+	}
+	if l := stmts[1].(*ForStmt); l.Body != nil || l.Cond == nil {
+		t.Errorf("unexpected for: %#v", l)
+	}
+	if l := stmts[2].(*ForEachStmt); l.Body != nil || l.Var != "s" {
+		t.Errorf("unexpected for each: %#v", l)
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	if _, err := Parse("", strings.NewReader("public class Foo {")); err == nil {
+		t.Errorf("expected syntax error")
+	}
+}
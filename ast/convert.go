@@ -0,0 +1,582 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Parse parses Apex source and converts it to a File.
+func Parse(filename string, reader io.Reader) (*File, error) {
+	tree, stream, err := formatter.Parse(filename, reader)
+	if err != nil {
+		return nil, err
+	}
+	return Convert(tree, stream), nil
+}
+
+// Convert converts a parse tree to a File.  tokens must be the stream the
+// tree was parsed from; it is used to find comments.
+func Convert(tree parser.ICompilationUnitContext, tokens *antlr.CommonTokenStream) *File {
+	c := &converter{tokens: tokens, attached: make(map[int]struct{})}
+	f := &File{Span: span(tree)}
+	if t := tree.TriggerUnit(); t != nil {
+		f.Decl = c.trigger(t)
+	} else if t := tree.TypeDeclaration(); t != nil {
+		f.Decl = c.typeDecl(t, t.AllModifier(), t.ClassDeclaration(), t.InterfaceDeclaration(), t.EnumDeclaration())
+	}
+	return f
+}
+
+type converter struct {
+	tokens   *antlr.CommonTokenStream
+	attached map[int]struct{}
+}
+
+func pos(t antlr.Token) Pos {
+	return Pos{Line: t.GetLine(), Column: t.GetColumn() + 1}
+}
+
+func endPos(t antlr.Token) Pos {
+	p := pos(t)
+	text := t.GetText()
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		p.Line += strings.Count(text, "\n")
+		p.Column = len([]rune(text[i+1:])) + 1
+	} else {
+		p.Column += len([]rune(text))
+	}
+	return p
+}
+
+func span(ctx antlr.ParserRuleContext) Span {
+	s := Span{Start: pos(ctx.GetStart())}
+	s.End = s.Start
+	if stop := ctx.GetStop(); stop != nil && stop.GetTokenIndex() >= ctx.GetStart().GetTokenIndex() {
+		s.End = endPos(stop)
+	}
+	return s
+}
+
+func tokenSpan(t antlr.Token) Span {
+	return Span{Start: pos(t), End: endPos(t)}
+}
+
+// comments returns the comments preceding ctx that have not been attached to
+// another node.
+func (c *converter) comments(ctx antlr.ParserRuleContext) []*Comment {
+	var comments []*Comment
+	for _, t := range c.tokens.GetHiddenTokensToLeft(ctx.GetStart().GetTokenIndex(), formatter.COMMENTS_CHANNEL) {
+		if _, seen := c.attached[t.GetTokenIndex()]; seen {
+			continue
+		}
+		c.attached[t.GetTokenIndex()] = struct{}{}
+		comments = append(comments, &Comment{Span: tokenSpan(t), Text: t.GetText()})
+	}
+	return comments
+}
+
+func (c *converter) text(ctx antlr.ParserRuleContext) string {
+	return c.tokens.GetTextFromRuleContext(ctx)
+}
+
+func (c *converter) modifiers(ctxs []parser.IModifierContext) Modifiers {
+	var m Modifiers
+	for _, mod := range ctxs {
+		if a := mod.Annotation(); a != nil {
+			annotation := &Annotation{Span: span(a), Name: a.QualifiedName().GetText()}
+			if a.LPAREN() != nil {
+				annotation.Args = c.tokens.GetTextFromTokens(a.LPAREN().GetSymbol(), a.RPAREN().GetSymbol())
+				annotation.Args = strings.TrimSuffix(strings.TrimPrefix(annotation.Args, "("), ")")
+			}
+			m.Annotations = append(m.Annotations, annotation)
+			continue
+		}
+		words := []string{}
+		for _, w := range mod.GetChildren() {
+			words = append(words, strings.ToLower(w.(antlr.TerminalNode).GetText()))
+		}
+		m.Keywords = append(m.Keywords, strings.Join(words, " "))
+	}
+	return m
+}
+
+func typeRef(ctx parser.ITypeRefContext) *TypeRef {
+	if ctx == nil {
+		return nil
+	}
+	return &TypeRef{Span: span(ctx), Name: strings.ReplaceAll(ctx.GetText(), ",", ", ")}
+}
+
+func typeList(ctx parser.ITypeListContext) []*TypeRef {
+	var types []*TypeRef
+	for _, t := range ctx.AllTypeRef() {
+		types = append(types, typeRef(t))
+	}
+	return types
+}
+
+func (c *converter) trigger(ctx parser.ITriggerUnitContext) *TriggerDecl {
+	t := &TriggerDecl{
+		Span:     span(ctx),
+		Comments: c.comments(ctx),
+		Name:     ctx.Id(0).GetText(),
+		Object:   ctx.Id(1).GetText(),
+	}
+	for _, e := range ctx.AllTriggerCase() {
+		t.Events = append(t.Events, strings.ToLower(e.GetChild(0).(antlr.TerminalNode).GetText()+" "+e.GetChild(1).(antlr.TerminalNode).GetText()))
+	}
+	for _, s := range ctx.TriggerBlock().AllTriggerStatement() {
+		if stmt := s.Statement(); stmt != nil {
+			t.Body = append(t.Body, c.stmt(stmt))
+		} else {
+			t.Body = append(t.Body, c.blockMember(s.BlockMemberDeclaration()))
+		}
+	}
+	return t
+}
+
+func (c *converter) typeDecl(ctx antlr.ParserRuleContext, modifiers []parser.IModifierContext, class parser.IClassDeclarationContext, iface parser.IInterfaceDeclarationContext, enum parser.IEnumDeclarationContext) Decl {
+	comments := c.comments(ctx)
+	mods := c.modifiers(modifiers)
+	switch {
+	case class != nil:
+		d := &ClassDecl{Span: span(ctx), Comments: comments, Modifiers: mods, Name: class.Id().GetText()}
+		if class.EXTENDS() != nil {
+			d.Extends = typeRef(class.TypeRef())
+		}
+		if class.IMPLEMENTS() != nil {
+			d.Implements = typeList(class.TypeList())
+		}
+		for _, b := range class.ClassBody().AllClassBodyDeclaration() {
+			if m := c.classBodyDecl(b); m != nil {
+				d.Members = append(d.Members, m)
+			}
+		}
+		return d
+	case iface != nil:
+		d := &InterfaceDecl{Span: span(ctx), Comments: comments, Modifiers: mods, Name: iface.Id().GetText()}
+		if iface.EXTENDS() != nil {
+			d.Extends = typeList(iface.TypeList())
+		}
+		for _, m := range iface.InterfaceBody().AllInterfaceMethodDeclaration() {
+			d.Methods = append(d.Methods, &MethodDecl{
+				Span:       span(m),
+				Comments:   c.comments(m),
+				Modifiers:  c.modifiers(m.AllModifier()),
+				ReturnType: typeRef(m.TypeRef()),
+				Name:       m.Id().GetText(),
+				Params:     c.params(m.FormalParameters()),
+			})
+		}
+		return d
+	case enum != nil:
+		d := &EnumDecl{Span: span(ctx), Comments: comments, Modifiers: mods, Name: enum.Id().GetText()}
+		if constants := enum.EnumConstants(); constants != nil {
+			for _, id := range constants.AllId() {
+				d.Values = append(d.Values, &Ident{Span: span(id), Name: id.GetText()})
+			}
+		}
+		return d
+	}
+	return nil
+}
+
+func (c *converter) classBodyDecl(ctx parser.IClassBodyDeclarationContext) Decl {
+	switch {
+	case ctx.Block() != nil:
+		return &InitializerDecl{Span: span(ctx), Comments: c.comments(ctx), Static: ctx.STATIC() != nil, Body: c.block(ctx.Block())}
+	case ctx.MemberDeclaration() != nil:
+		return c.member(ctx, ctx.AllModifier(), ctx.MemberDeclaration())
+	}
+	return nil
+}
+
+func (c *converter) blockMember(ctx parser.IBlockMemberDeclarationContext) Stmt {
+	return &DeclStmt{Span: span(ctx), Decl: c.member(ctx, ctx.AllModifier(), ctx.MemberDeclaration())}
+}
+
+func (c *converter) member(ctx antlr.ParserRuleContext, modifiers []parser.IModifierContext, member parser.IMemberDeclarationContext) Decl {
+	if member.ClassDeclaration() != nil || member.InterfaceDeclaration() != nil || member.EnumDeclaration() != nil {
+		return c.typeDecl(ctx, modifiers, member.ClassDeclaration(), member.InterfaceDeclaration(), member.EnumDeclaration())
+	}
+	comments := c.comments(ctx)
+	mods := c.modifiers(modifiers)
+	switch {
+	case member.FieldDeclaration() != nil:
+		f := member.FieldDeclaration()
+		return &FieldDecl{Span: span(ctx), Comments: comments, Modifiers: mods, Type: typeRef(f.TypeRef()), Vars: c.vars(f.VariableDeclarators())}
+	case member.PropertyDeclaration() != nil:
+		p := member.PropertyDeclaration()
+		d := &PropertyDecl{Span: span(ctx), Comments: comments, Modifiers: mods, Type: typeRef(p.TypeRef()), Name: p.Id().GetText()}
+		for _, b := range p.AllPropertyBlock() {
+			a := &Accessor{Span: span(b), Modifiers: c.modifiers(b.AllModifier())}
+			if g := b.Getter(); g != nil {
+				if g.Block() != nil {
+					a.Body = c.block(g.Block())
+				}
+				d.Getter = a
+			} else if s := b.Setter(); s != nil {
+				if s.Block() != nil {
+					a.Body = c.block(s.Block())
+				}
+				d.Setter = a
+			}
+		}
+		return d
+	case member.ConstructorDeclaration() != nil:
+		k := member.ConstructorDeclaration()
+		return &ConstructorDecl{Span: span(ctx), Comments: comments, Modifiers: mods, Name: k.QualifiedName().GetText(), Params: c.params(k.FormalParameters()), Body: c.block(k.Block())}
+	case member.MethodDeclaration() != nil:
+		m := member.MethodDeclaration()
+		d := &MethodDecl{Span: span(ctx), Comments: comments, Modifiers: mods, ReturnType: typeRef(m.TypeRef()), Name: m.Id().GetText(), Params: c.params(m.FormalParameters())}
+		if m.Block() != nil {
+			d.Body = c.block(m.Block())
+		}
+		return d
+	}
+	return nil
+}
+
+func (c *converter) params(ctx parser.IFormalParametersContext) []*Param {
+	var params []*Param
+	if list := ctx.FormalParameterList(); list != nil {
+		for _, p := range list.AllFormalParameter() {
+			params = append(params, &Param{Span: span(p), Modifiers: c.modifiers(p.AllModifier()), Type: typeRef(p.TypeRef()), Name: p.Id().GetText()})
+		}
+	}
+	return params
+}
+
+func (c *converter) vars(ctx parser.IVariableDeclaratorsContext) []*VarDecl {
+	var vars []*VarDecl
+	for _, v := range ctx.AllVariableDeclarator() {
+		d := &VarDecl{Span: span(v), Name: v.Id().GetText()}
+		if v.Expression() != nil {
+			d.Init = c.expr(v.Expression())
+		}
+		vars = append(vars, d)
+	}
+	return vars
+}
+
+func (c *converter) block(ctx parser.IBlockContext) *Block {
+	b := &Block{Span: span(ctx), Comments: c.comments(ctx)}
+	for _, s := range ctx.AllStatement() {
+		b.Stmts = append(b.Stmts, c.stmt(s))
+	}
+	return b
+}
+
+func (c *converter) localVar(ctx parser.ILocalVariableDeclarationContext, comments []*Comment) *LocalVarStmt {
+	return &LocalVarStmt{Span: span(ctx), Comments: comments, Modifiers: c.modifiers(ctx.AllModifier()), Type: typeRef(ctx.TypeRef()), Vars: c.vars(ctx.VariableDeclarators())}
+}
+
+func (c *converter) stmt(ctx parser.IStatementContext) Stmt {
+	comments := c.comments(ctx)
+	sp := span(ctx)
+	switch {
+	case ctx.Block() != nil:
+		b := c.block(ctx.Block())
+		b.Comments = append(comments, b.Comments...)
+		return b
+	case ctx.LocalVariableDeclarationStatement() != nil:
+		s := c.localVar(ctx.LocalVariableDeclarationStatement().LocalVariableDeclaration(), comments)
+		s.Span = sp
+		return s
+	case ctx.ExpressionStatement() != nil:
+		return &ExprStmt{Span: sp, Comments: comments, X: c.expr(ctx.ExpressionStatement().Expression())}
+	case ctx.IfStatement() != nil:
+		i := ctx.IfStatement()
+		s := &IfStmt{Span: sp, Comments: comments, Cond: c.expr(i.ParExpression().Expression()), Then: c.stmt(i.Statement(0))}
+		if i.ELSE() != nil {
+			s.Else = c.stmt(i.Statement(1))
+		}
+		return s
+	case ctx.SwitchStatement() != nil:
+		sw := ctx.SwitchStatement()
+		s := &SwitchStmt{Span: sp, Comments: comments, X: c.expr(sw.Expression())}
+		for _, w := range sw.AllWhenControl() {
+			s.Whens = append(s.Whens, c.when(w))
+		}
+		return s
+	case ctx.ForStatement() != nil:
+		f := ctx.ForStatement()
+		control := f.ForControl()
+		var body Stmt
+		if f.Statement() != nil {
+			body = c.stmt(f.Statement())
+		}
+		if e := control.EnhancedForControl(); e != nil {
+			return &ForEachStmt{Span: sp, Comments: comments, Type: typeRef(e.TypeRef()), Var: e.Id().GetText(), X: c.expr(e.Expression()), Body: body}
+		}
+		s := &ForStmt{Span: sp, Comments: comments, Body: body}
+		if init := control.ForInit(); init != nil {
+			if l := init.LocalVariableDeclaration(); l != nil {
+				s.Init = c.localVar(l, nil)
+			} else {
+				s.Init = &ExprListStmt{Span: span(init), List: c.exprList(init.ExpressionList())}
+			}
+		}
+		if control.Expression() != nil {
+			s.Cond = c.expr(control.Expression())
+		}
+		if update := control.ForUpdate(); update != nil {
+			s.Update = c.exprList(update.ExpressionList())
+		}
+		return s
+	case ctx.WhileStatement() != nil:
+		w := ctx.WhileStatement()
+		s := &WhileStmt{Span: sp, Comments: comments, Cond: c.expr(w.ParExpression().Expression())}
+		if w.Statement() != nil {
+			s.Body = c.stmt(w.Statement())
+		}
+		return s
+	case ctx.DoWhileStatement() != nil:
+		d := ctx.DoWhileStatement()
+		return &DoWhileStmt{Span: sp, Comments: comments, Body: c.stmt(d.Statement()), Cond: c.expr(d.ParExpression().Expression())}
+	case ctx.TryStatement() != nil:
+		t := ctx.TryStatement()
+		s := &TryStmt{Span: sp, Comments: comments, Body: c.block(t.Block())}
+		for _, k := range t.AllCatchClause() {
+			s.Catches = append(s.Catches, &CatchClause{Span: span(k), Modifiers: c.modifiers(k.AllModifier()), Type: k.QualifiedName().GetText(), Name: k.Id().GetText(), Body: c.block(k.Block())})
+		}
+		if f := t.FinallyBlock(); f != nil {
+			s.Finally = c.block(f.Block())
+		}
+		return s
+	case ctx.ReturnStatement() != nil:
+		s := &ReturnStmt{Span: sp, Comments: comments}
+		if x := ctx.ReturnStatement().Expression(); x != nil {
+			s.X = c.expr(x)
+		}
+		return s
+	case ctx.ThrowStatement() != nil:
+		return &ThrowStmt{Span: sp, Comments: comments, X: c.expr(ctx.ThrowStatement().Expression())}
+	case ctx.BreakStatement() != nil:
+		return &BreakStmt{Span: sp, Comments: comments}
+	case ctx.ContinueStatement() != nil:
+		return &ContinueStmt{Span: sp, Comments: comments}
+	case ctx.InsertStatement() != nil:
+		return &DMLStmt{Span: sp, Comments: comments, Op: "insert", X: c.expr(ctx.InsertStatement().Expression())}
+	case ctx.UpdateStatement() != nil:
+		return &DMLStmt{Span: sp, Comments: comments, Op: "update", X: c.expr(ctx.UpdateStatement().Expression())}
+	case ctx.DeleteStatement() != nil:
+		return &DMLStmt{Span: sp, Comments: comments, Op: "delete", X: c.expr(ctx.DeleteStatement().Expression())}
+	case ctx.UndeleteStatement() != nil:
+		return &DMLStmt{Span: sp, Comments: comments, Op: "undelete", X: c.expr(ctx.UndeleteStatement().Expression())}
+	case ctx.UpsertStatement() != nil:
+		u := ctx.UpsertStatement()
+		s := &DMLStmt{Span: sp, Comments: comments, Op: "upsert", X: c.expr(u.Expression())}
+		if u.QualifiedName() != nil {
+			s.ExternalID = u.QualifiedName().GetText()
+		}
+		return s
+	case ctx.MergeStatement() != nil:
+		m := ctx.MergeStatement()
+		return &DMLStmt{Span: sp, Comments: comments, Op: "merge", X: c.expr(m.Expression(0)), Y: c.expr(m.Expression(1))}
+	case ctx.RunAsStatement() != nil:
+		r := ctx.RunAsStatement()
+		s := &RunAsStmt{Span: sp, Comments: comments, Body: c.block(r.Block())}
+		if r.ExpressionList() != nil {
+			s.Args = c.exprList(r.ExpressionList())
+		}
+		return s
+	}
+	panic(fmt.Sprintf("unexpected statement %T", ctx.GetChild(0)))
+}
+
+func (c *converter) when(ctx parser.IWhenControlContext) *WhenClause {
+	w := &WhenClause{Span: span(ctx), Body: c.block(ctx.Block())}
+	v := ctx.WhenValue()
+	switch {
+	case v.ELSE() != nil:
+		w.Else = true
+	case len(v.AllId()) == 2:
+		w.Type = v.Id(0).GetText()
+		w.Var = v.Id(1).GetText()
+	default:
+		for _, l := range v.AllWhenLiteral() {
+			w.Values = append(w.Values, l.GetText())
+		}
+	}
+	return w
+}
+
+func (c *converter) exprList(ctx parser.IExpressionListContext) []Expr {
+	var list []Expr
+	for _, e := range ctx.AllExpression() {
+		list = append(list, c.expr(e))
+	}
+	return list
+}
+
+func (c *converter) args(ctx parser.IExpressionListContext) []Expr {
+	if ctx == nil {
+		return nil
+	}
+	return c.exprList(ctx)
+}
+
+// operator returns the text of the terminal children of ctx, which make up
+// its operator, e.g. ">=" for a comparison lexed as GT ASSIGN.
+func operator(ctx antlr.ParserRuleContext) string {
+	var op strings.Builder
+	for _, child := range ctx.GetChildren() {
+		if t, ok := child.(antlr.TerminalNode); ok {
+			op.WriteString(t.GetText())
+		}
+	}
+	return op.String()
+}
+
+type binaryExpression interface {
+	antlr.ParserRuleContext
+	Expression(i int) parser.IExpressionContext
+}
+
+func (c *converter) expr(ctx parser.IExpressionContext) Expr {
+	sp := span(ctx)
+	switch e := ctx.(type) {
+	case *parser.PrimaryExpressionContext:
+		return c.primary(e.Primary())
+	case *parser.DotExpressionContext:
+		if m := e.DotMethodCall(); m != nil {
+			return &CallExpr{Span: sp, Recv: c.expr(e.Expression()), Name: m.AnyId().GetText(), Args: c.args(m.ExpressionList()), SafeNav: e.QUESTIONDOT() != nil}
+		}
+		return &SelectorExpr{Span: sp, X: c.expr(e.Expression()), Name: e.AnyId().GetText(), SafeNav: e.QUESTIONDOT() != nil}
+	case *parser.MethodCallExpressionContext:
+		m := e.MethodCall()
+		name := ""
+		switch {
+		case m.Id() != nil:
+			name = m.Id().GetText()
+		case m.THIS() != nil:
+			name = "this"
+		case m.SUPER() != nil:
+			name = "super"
+		}
+		return &CallExpr{Span: sp, Name: name, Args: c.args(m.ExpressionList())}
+	case *parser.ArrayExpressionContext:
+		return &IndexExpr{Span: sp, X: c.expr(e.Expression(0)), Index: c.expr(e.Expression(1))}
+	case *parser.AssignExpressionContext:
+		return &AssignExpr{Span: sp, Op: operator(e), Lhs: c.expr(e.Expression(0)), Rhs: c.expr(e.Expression(1))}
+	case *parser.NewInstanceExpressionContext:
+		return c.creator(sp, e.Creator())
+	case *parser.CastExpressionContext:
+		return &CastExpr{Span: sp, Type: typeRef(e.TypeRef()), X: c.expr(e.Expression())}
+	case *parser.SubExpressionContext:
+		return &ParenExpr{Span: sp, X: c.expr(e.Expression())}
+	case *parser.PostOpExpressionContext:
+		return &UnaryExpr{Span: sp, Op: operator(e), X: c.expr(e.Expression()), Postfix: true}
+	case *parser.PreOpExpressionContext:
+		return &UnaryExpr{Span: sp, Op: operator(e), X: c.expr(e.Expression())}
+	case *parser.NegExpressionContext:
+		return &UnaryExpr{Span: sp, Op: operator(e), X: c.expr(e.Expression())}
+	case *parser.InstanceOfExpressionContext:
+		return &InstanceOfExpr{Span: sp, X: c.expr(e.Expression()), Type: typeRef(e.TypeRef())}
+	case *parser.CondExpressionContext:
+		return &CondExpr{Span: sp, Cond: c.expr(e.Expression(0)), Then: c.expr(e.Expression(1)), Else: c.expr(e.Expression(2))}
+	case binaryExpression:
+		return &BinaryExpr{Span: sp, Op: operator(e), X: c.expr(e.Expression(0)), Y: c.expr(e.Expression(1))}
+	}
+	panic(fmt.Sprintf("unexpected expression %T", ctx))
+}
+
+func (c *converter) primary(ctx parser.IPrimaryContext) Expr {
+	sp := span(ctx)
+	switch p := ctx.(type) {
+	case *parser.ThisPrimaryContext:
+		return &ThisExpr{Span: sp}
+	case *parser.SuperPrimaryContext:
+		return &SuperExpr{Span: sp}
+	case *parser.IdPrimaryContext:
+		return &Ident{Span: sp, Name: p.Id().GetText()}
+	case *parser.TypeRefPrimaryContext:
+		return &ClassLit{Span: sp, Type: typeRef(p.TypeRef())}
+	case *parser.LiteralPrimaryContext:
+		l := p.Literal()
+		lit := &BasicLit{Span: sp, Value: l.GetText()}
+		switch {
+		case l.IntegerLiteral() != nil:
+			lit.Kind = IntegerLit
+		case l.LongLiteral() != nil:
+			lit.Kind = LongLit
+		case l.NumberLiteral() != nil:
+			lit.Kind = NumberLit
+		case l.StringLiteral() != nil:
+			lit.Kind = StringLit
+		case l.BooleanLiteral() != nil:
+			lit.Kind = BooleanLit
+		case l.NULL() != nil:
+			lit.Kind = NullLit
+		}
+		return lit
+	case *parser.SoqlPrimaryContext:
+		soql := p.SoqlLiteral()
+		q := soql.Query()
+		s := &SOQLQuery{Span: sp, Text: c.text(q), Binds: c.binds(soql)}
+		for _, f := range q.SelectList().AllSelectEntry() {
+			s.Fields = append(s.Fields, c.text(f))
+		}
+		for _, f := range q.FromNameList().AllFieldNameAlias() {
+			s.From = append(s.From, c.text(f))
+		}
+		return s
+	case *parser.SoslPrimaryContext:
+		sosl := p.SoslLiteral()
+		text := c.text(sosl)
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, "["), "]"))
+		return &SOSLQuery{Span: sp, Text: text, Binds: c.binds(sosl)}
+	}
+	panic(fmt.Sprintf("unexpected primary %T", ctx))
+}
+
+// binds returns the bound Apex expressions in a SOQL or SOSL literal.
+func (c *converter) binds(ctx antlr.ParserRuleContext) []Expr {
+	var binds []Expr
+	var walk func(antlr.Tree)
+	walk = func(t antlr.Tree) {
+		if b, ok := t.(*parser.BoundExpressionContext); ok {
+			binds = append(binds, c.expr(b.Expression()))
+			return
+		}
+		for _, child := range t.GetChildren() {
+			walk(child)
+		}
+	}
+	walk(ctx)
+	return binds
+}
+
+func (c *converter) creator(sp Span, ctx parser.ICreatorContext) Expr {
+	name := ctx.CreatedName()
+	n := &NewExpr{Span: sp, Type: &TypeRef{Span: span(name), Name: strings.ReplaceAll(name.GetText(), ",", ", ")}}
+	switch {
+	case ctx.ClassCreatorRest() != nil:
+		n.Args = c.args(ctx.ClassCreatorRest().Arguments().ExpressionList())
+	case ctx.ArrayCreatorRest() != nil:
+		a := ctx.ArrayCreatorRest()
+		if a.Expression() != nil {
+			n.Size = c.expr(a.Expression())
+		}
+		if init := a.ArrayInitializer(); init != nil {
+			for _, e := range init.AllExpression() {
+				n.Elems = append(n.Elems, c.expr(e))
+			}
+		}
+	case ctx.MapCreatorRest() != nil:
+		for _, p := range ctx.MapCreatorRest().AllMapCreatorRestPair() {
+			n.Pairs = append(n.Pairs, &MapPair{Span: span(p), Key: c.expr(p.Expression(0)), Value: c.expr(p.Expression(1))})
+		}
+	case ctx.SetCreatorRest() != nil:
+		for _, e := range ctx.SetCreatorRest().AllExpression() {
+			n.Elems = append(n.Elems, c.expr(e))
+		}
+	}
+	return n
+}
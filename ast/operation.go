@@ -0,0 +1,34 @@
+package ast
+
+import "strings"
+
+// OperationKind classifies calls to system methods that query or modify
+// records.
+type OperationKind int
+
+const (
+	NoOperation OperationKind = iota
+	// QueryOperation is a SOQL query or SOSL search, such as Database.query.
+	QueryOperation
+	// DMLOperation modifies records, such as Database.insert.
+	DMLOperation
+)
+
+// Operation classifies a call of method on receiver, e.g. "Database" and
+// "query".  Names are compared ignoring case.
+func Operation(receiver, method string) OperationKind {
+	switch strings.ToLower(receiver) {
+	case "database":
+		switch strings.ToLower(method) {
+		case "query", "querywithbinds", "countquery", "countquerywithbinds", "getquerylocator", "getquerylocatorwithbinds":
+			return QueryOperation
+		case "insert", "update", "upsert", "delete", "undelete", "merge", "convertlead", "emptyrecyclebin":
+			return DMLOperation
+		}
+	case "search":
+		if strings.EqualFold(method, "query") {
+			return QueryOperation
+		}
+	}
+	return NoOperation
+}
@@ -0,0 +1,40 @@
+package ast
+
+import "reflect"
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Inspect traverses the tree rooted at node in depth-first order, calling f
+// for each node, including comments, annotations and type references.  If f
+// returns false, the children of the node are skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || reflect.ValueOf(node).IsNil() || !f(node) {
+		return
+	}
+	walkValue(reflect.ValueOf(node).Elem(), f)
+}
+
+func walkValue(v reflect.Value, f func(Node) bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			if field.Type() == reflect.TypeOf(Modifiers{}) {
+				walkValue(field, f)
+			}
+		case reflect.Ptr, reflect.Interface:
+			if !field.IsNil() && field.Type().Implements(nodeType) {
+				Inspect(field.Interface().(Node), f)
+			}
+		case reflect.Slice:
+			if !field.Type().Elem().Implements(nodeType) {
+				continue
+			}
+			for j := 0; j < field.Len(); j++ {
+				if e := field.Index(j); !e.IsNil() {
+					Inspect(e.Interface().(Node), f)
+				}
+			}
+		}
+	}
+}
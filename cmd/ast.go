@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/octoberswimmer/apexfmt/parsetree"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	astCmd.Flags().Bool("hidden", false, "include whitespace and comment tokens")
+	astCmd.Flags().Bool("pretty", false, "indent JSON output")
+	astCmd.Flags().Bool("schema", false, "print the JSON Schema of the output and exit")
+	RootCmd.AddCommand(astCmd)
+}
+
+var astCmd = &cobra.Command{
+	Use:   "ast [file]",
+	Short: "Print the Apex parse tree as JSON",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if schema, _ := cmd.Flags().GetBool("schema"); schema {
+			fmt.Print(parsetree.Schema)
+			return nil
+		}
+		hidden, _ := cmd.Flags().GetBool("hidden")
+		pretty, _ := cmd.Flags().GetBool("pretty")
+		var doc *parsetree.Document
+		var err error
+		if len(args) == 0 {
+			doc, err = parsetree.Parse("", os.Stdin, hidden)
+		} else {
+			doc, err = parsetree.Parse(args[0], nil, hidden)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		if pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(doc)
+	},
+	DisableFlagsInUseLine: true,
+}
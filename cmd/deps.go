@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/deps"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	depsCmd.Flags().String("format", "dot", "output format: "+strings.Join(deps.Formats, ", "))
+	depsCmd.Flags().Bool("cycles", false, "list dependency cycles instead of the graph, and fail if there are any")
+	RootCmd.AddCommand(depsCmd)
+}
+
+var depsCmd = &cobra.Command{
+	Use:   "deps path...",
+	Short: "Print the dependency graph of Apex classes and triggers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if !deps.Valid(format) {
+			return fmt.Errorf("Unsupported format: %s", format)
+		}
+		files, err := deps.Files(args)
+		if err != nil {
+			return err
+		}
+		g, err := deps.Build(files)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		if cycles, _ := cmd.Flags().GetBool("cycles"); cycles {
+			for _, c := range g.Cycles {
+				fmt.Println(strings.Join(c, " "))
+			}
+			if len(g.Cycles) > 0 {
+				os.Exit(1)
+			}
+			return nil
+		}
+		return deps.Write(os.Stdout, format, g)
+	},
+	DisableFlagsInUseLine: true,
+}
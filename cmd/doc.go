@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/apexdoc"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	docCmd.Flags().StringP("output", "o", "doc", "directory to write documentation to")
+	docCmd.Flags().String("format", "markdown", "output format: "+strings.Join(apexdoc.Formats, ", "))
+	docCmd.Flags().Bool("private", false, "include private declarations")
+	RootCmd.AddCommand(docCmd)
+}
+
+var docCmd = &cobra.Command{
+	Use:   "doc file...",
+	Short: "Generate API reference documentation from ApexDoc comments",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		format, _ := cmd.Flags().GetString("format")
+		private, _ := cmd.Flags().GetBool("private")
+		if format != "markdown" && format != "html" {
+			return fmt.Errorf("Unsupported format: %s", format)
+		}
+		pages := []apexdoc.Page{}
+		for _, filename := range args {
+			t, err := apexdoc.Parse(filename, nil)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			if t == nil || !apexdoc.Visible(t.Access, private) {
+				continue
+			}
+			pages = append(pages, apexdoc.Pages(t.Filter(private))...)
+		}
+		sort.SliceStable(pages, func(i, j int) bool {
+			return strings.ToLower(pages[i].Name) < strings.ToLower(pages[j].Name)
+		})
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return err
+		}
+		for _, p := range pages {
+			if err := writeDocPage(filepath.Join(output, p.Name+apexdoc.Extension(format)), func(f *os.File) error {
+				return apexdoc.WritePage(f, format, p)
+			}); err != nil {
+				return err
+			}
+		}
+		return writeDocPage(filepath.Join(output, "index"+apexdoc.Extension(format)), func(f *os.File) error {
+			return apexdoc.WriteIndex(f, format, pages)
+		})
+	},
+	DisableFlagsInUseLine: true,
+}
+
+func writeDocPage(filename string, write func(*os.File) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return fmt.Errorf("Failed to write %s: %w", filename, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/lint"
+	"github.com/octoberswimmer/apexfmt/report"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	lintCmd.Flags().StringSlice("disable", []string{}, "rules to disable")
+	lintCmd.Flags().StringSlice("enable", []string{}, "optional rules to enable")
+	lintCmd.Flags().Bool("rules", false, "list available rules")
+	lintCmd.Flags().String("format", "text", "output format: "+strings.Join(report.Formats, ", "))
+	RootCmd.AddCommand(lintCmd)
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file...]",
+	Short: "Report common problems in Apex",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if listRules, _ := cmd.Flags().GetBool("rules"); listRules {
+			for _, r := range lint.DefaultRules() {
+				fmt.Println(r.Name())
+			}
+			for _, r := range lint.OptionalRules() {
+				fmt.Println(r.Name(), "(optional)")
+			}
+			return nil
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if !report.Valid(format) {
+			return fmt.Errorf("Unsupported format: %s", format)
+		}
+		disabled, _ := cmd.Flags().GetStringSlice("disable")
+		enabled, _ := cmd.Flags().GetStringSlice("enable")
+		rules, err := enabledRules(disabled, enabled)
+		if err != nil {
+			return err
+		}
+		project := lint.NewProject()
+		for _, filename := range args {
+			// syntax errors are reported when the file is linted
+			var syntaxErrors *formatter.SyntaxErrors
+			if err := project.Add(filename, nil); err != nil && !errors.As(err, &syntaxErrors) {
+				fmt.Fprintf(os.Stderr, "Failed to lint file %s: %s\n", filename, err.Error())
+				os.Exit(1)
+			}
+		}
+		linters := []*lint.Linter{}
+		for _, filename := range args {
+			l := lint.NewLinter(filename, nil, rules)
+			l.SetProject(project)
+			linters = append(linters, l)
+		}
+		if len(args) == 0 {
+			linters = append(linters, lint.NewLinter("", os.Stdin, rules))
+		}
+		entries := []report.Entry{}
+		for _, l := range linters {
+			diagnostics, err := l.Lint()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to lint file %s: %s\n", l.SourceName(), err.Error())
+				os.Exit(1)
+			}
+			for _, d := range diagnostics {
+				if format == "text" {
+					fmt.Printf("%s:%s\n", l.SourceName(), d)
+				}
+				entries = append(entries, report.Entry{
+					File:    l.SourceName(),
+					Line:    d.Line,
+					Column:  d.Column,
+					Rule:    d.Rule,
+					Message: d.Message,
+				})
+			}
+		}
+		if format != "text" {
+			if err := report.Write(os.Stdout, format, entries); err != nil {
+				return err
+			}
+		}
+		if len(entries) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
+
+func enabledRules(disabled, enabled []string) ([]lint.Rule, error) {
+	skip := make(map[string]struct{})
+	for _, name := range disabled {
+		skip[name] = struct{}{}
+	}
+	rules := []lint.Rule{}
+	for _, r := range lint.DefaultRules() {
+		if _, ok := skip[r.Name()]; ok {
+			delete(skip, r.Name())
+			continue
+		}
+		rules = append(rules, r)
+	}
+	for name := range skip {
+		return nil, fmt.Errorf("Unknown rule: %s", name)
+	}
+	optional := make(map[string]lint.Rule)
+	for _, r := range lint.OptionalRules() {
+		optional[r.Name()] = r
+	}
+	for _, name := range enabled {
+		r, ok := optional[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown optional rule: %s", name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
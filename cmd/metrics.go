@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/metrics"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	metricsCmd.Flags().String("format", "table", "output format: "+strings.Join(metrics.Formats, ", "))
+	metricsCmd.Flags().Int("max-complexity", 0, "fail if a method's cyclomatic complexity exceeds this")
+	metricsCmd.Flags().Int("max-nesting", 0, "fail if a method's nesting depth exceeds this")
+	metricsCmd.Flags().Int("max-statements", 0, "fail if a method's statement count exceeds this")
+	metricsCmd.Flags().Int("max-parameters", 0, "fail if a method's parameter count exceeds this")
+	metricsCmd.Flags().Int("max-soql", 0, "fail if a method's SOQL query count exceeds this")
+	metricsCmd.Flags().Int("max-dml", 0, "fail if a method's DML statement count exceeds this")
+	RootCmd.AddCommand(metricsCmd)
+}
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics [file...]",
+	Short: "Report complexity, nesting and size metrics for Apex",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if !metrics.Valid(format) {
+			return fmt.Errorf("Unsupported format: %s", format)
+		}
+		var limits metrics.Metrics
+		limits.Complexity, _ = cmd.Flags().GetInt("max-complexity")
+		limits.Nesting, _ = cmd.Flags().GetInt("max-nesting")
+		limits.Statements, _ = cmd.Flags().GetInt("max-statements")
+		limits.Parameters, _ = cmd.Flags().GetInt("max-parameters")
+		limits.SOQL, _ = cmd.Flags().GetInt("max-soql")
+		limits.DML, _ = cmd.Flags().GetInt("max-dml")
+
+		files := []metrics.File{}
+		parse := func(filename string, name string) {
+			var types []*metrics.Type
+			var err error
+			if filename == "" {
+				types, err = metrics.Parse("", os.Stdin)
+			} else {
+				types, err = metrics.Parse(filename, nil)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			files = append(files, metrics.File{File: name, Types: types})
+		}
+		for _, filename := range args {
+			parse(filename, filename)
+		}
+		if len(args) == 0 {
+			parse("", "<stdin>")
+		}
+		if err := metrics.Write(os.Stdout, format, files); err != nil {
+			return err
+		}
+		violations := metrics.Check(files, limits)
+		for _, v := range violations {
+			fmt.Fprintln(os.Stderr, v)
+		}
+		if len(violations) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
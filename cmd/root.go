@@ -1,10 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/octoberswimmer/apexfmt/diff"
 	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/report"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -17,33 +22,73 @@ func init() {
 	cobra.OnInitialize(globalConfig)
 	RootCmd.Flags().BoolP("write", "w", false, "write result to (source) file instead of stdout")
 	RootCmd.Flags().BoolP("list", "l", false, "list files whose formatting differs from apexfmt's")
+	RootCmd.Flags().BoolP("diff", "d", false, "display diffs instead of the formatted source")
+	RootCmd.Flags().Bool("check", false, "exit with a non-zero status if any file's formatting differs from apexfmt's")
 	RootCmd.Flags().BoolP("verbose", "v", false, "enable debug logging")
-	RootCmd.Flags().BoolP("soql", "s", false, "format SOQL query")
+	RootCmd.Flags().BoolP("soql", "s", false, "format SOQL query or SOSL search")
+	RootCmd.Flags().Bool("simplify", false, "simplify code, e.g. remove redundant parentheses and this qualifiers")
+	RootCmd.Flags().Bool("sort-modifiers", false, "order modifiers canonically, e.g. public static final")
+	RootCmd.Flags().Bool("normalize-annotations", false, "use canonical capitalization for known annotations, e.g. @IsTest")
+	RootCmd.Flags().StringSlice("inline-annotations", []string{}, "annotations without arguments to keep on the declaration's line (e.g., TestVisible)")
+	RootCmd.Flags().Bool("sort-trigger-events", false, "order trigger events canonically, e.g. before insert, before update, after insert")
+	RootCmd.Flags().Bool("sort-members", false, "order class members by group")
+	RootCmd.Flags().StringSlice("member-order", formatter.MemberGroups, "member groups in order for --sort-members")
+	RootCmd.Flags().Int("max-width", formatter.DefaultMaxWidth, "line length beyond which lists such as trigger events, enum constants and extended interfaces are wrapped")
+	RootCmd.Flags().Int("max-blank-lines", formatter.DefaultMaxBlankLines, "maximum number of consecutive blank lines to preserve")
+	RootCmd.Flags().Bool("separate-members", false, "require a blank line around methods, constructors, properties and inner types")
+	RootCmd.Flags().Bool("trim-blocks", false, "remove blank lines at the start and end of blocks")
+	RootCmd.Flags().String("brace-style", "kr", "brace style for class, interface, constructor and method bodies: kr, allman")
+	RootCmd.Flags().Bool("keep-guards", false, "keep brace-less single-line if guards, e.g. if (x) return;")
+	RootCmd.Flags().Bool("doc-comments", false, "reformat ApexDoc comments: normalize gutters, reflow text, align @param and order tags")
+	RootCmd.Flags().Int("doc-comment-width", formatter.DefaultDocCommentWidth, "maximum width of reformatted ApexDoc comment lines, excluding indentation")
+	RootCmd.Flags().Bool("add-doc-stubs", false, "insert ApexDoc templates for global and public classes and methods without ApexDoc")
+	RootCmd.Flags().Bool("compact", false, "print code with minimal whitespace to reduce its size")
+	RootCmd.Flags().Bool("strip-comments", false, "remove comments other than ApexDoc comments")
+	RootCmd.Flags().Bool("remove-unused-locals", false, "remove local variables that are never read and whose initializers have no side effects")
+	RootCmd.Flags().StringArrayP("rewrite", "r", []string{}, "rewrite rule (e.g., 'System.assertEquals(a, b) -> Assert.areEqual(a, b)'); may be repeated")
+	RootCmd.Flags().String("format", "text", "output format for --list: "+strings.Join(report.Formats, ", "))
 
 	RootCmd.MarkFlagsMutuallyExclusive("write", "list")
-	RootCmd.MarkFlagsMutuallyExclusive("soql", "write")
-	RootCmd.MarkFlagsMutuallyExclusive("soql", "list")
+	RootCmd.Args = cobra.ArbitraryArgs
+}
 
+type sourceFormatter interface {
+	Format() error
+	Formatted() (string, error)
+	Changed() (bool, error)
+	Write() error
+	Source() []byte
+	SourceName() string
+	SetOptions(formatter.Options)
 }
 
 var RootCmd = &cobra.Command{
 	Use:   "apexfmt [file...]",
 	Short: "Format Apex",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if soql, _ := cmd.Flags().GetBool("soql"); soql {
-			formatSOQL()
-			return nil
-		}
-
+		soql, _ := cmd.Flags().GetBool("soql")
 		write, _ := cmd.Flags().GetBool("write")
 		list, _ := cmd.Flags().GetBool("list")
+		showDiff, _ := cmd.Flags().GetBool("diff")
+		check, _ := cmd.Flags().GetBool("check")
 		verbose, _ := cmd.Flags().GetBool("verbose")
 		if verbose {
 			log.SetLevel(log.DebugLevel)
 		}
-		formatters := []*formatter.Formatter{}
+		format, _ := cmd.Flags().GetString("format")
+		if !report.Valid(format) {
+			return fmt.Errorf("Unsupported format: %s", format)
+		}
+		if format != "text" && !list {
+			return fmt.Errorf("--format requires the --list option")
+		}
+		formatters := []sourceFormatter{}
 		for _, filename := range args {
-			formatters = append(formatters, formatter.NewFormatter(filename, nil))
+			if soql || isQueryFile(filename) {
+				formatters = append(formatters, formatter.NewSOQLFormatter(filename, nil))
+			} else {
+				formatters = append(formatters, formatter.NewFormatter(filename, nil))
+			}
 		}
 		if len(args) == 0 {
 			if write {
@@ -52,10 +97,84 @@ var RootCmd = &cobra.Command{
 			if list {
 				return fmt.Errorf("One or more files required for --list option")
 			}
-			formatters = append(formatters, formatter.NewFormatter("", os.Stdin))
+			if soql {
+				formatters = append(formatters, formatter.NewSOQLFormatter("", os.Stdin))
+			} else {
+				formatters = append(formatters, formatter.NewFormatter("", os.Stdin))
+			}
+		}
+		simplify, _ := cmd.Flags().GetBool("simplify")
+		sortModifiers, _ := cmd.Flags().GetBool("sort-modifiers")
+		normalizeAnnotations, _ := cmd.Flags().GetBool("normalize-annotations")
+		inlineAnnotations, _ := cmd.Flags().GetStringSlice("inline-annotations")
+		sortTriggerEvents, _ := cmd.Flags().GetBool("sort-trigger-events")
+		sortMembers, _ := cmd.Flags().GetBool("sort-members")
+		memberOrder, _ := cmd.Flags().GetStringSlice("member-order")
+		maxWidth, _ := cmd.Flags().GetInt("max-width")
+		maxBlankLines, _ := cmd.Flags().GetInt("max-blank-lines")
+		if maxBlankLines < 0 {
+			return fmt.Errorf("--max-blank-lines must not be negative")
+		}
+		separateMembers, _ := cmd.Flags().GetBool("separate-members")
+		trimBlocks, _ := cmd.Flags().GetBool("trim-blocks")
+		braceStyle, _ := cmd.Flags().GetString("brace-style")
+		if braceStyle != "kr" && braceStyle != "allman" {
+			return fmt.Errorf("Unsupported brace style: %s", braceStyle)
+		}
+		keepGuards, _ := cmd.Flags().GetBool("keep-guards")
+		docComments, _ := cmd.Flags().GetBool("doc-comments")
+		docCommentWidth, _ := cmd.Flags().GetInt("doc-comment-width")
+		addDocStubs, _ := cmd.Flags().GetBool("add-doc-stubs")
+		removeUnusedLocals, _ := cmd.Flags().GetBool("remove-unused-locals")
+		compact, _ := cmd.Flags().GetBool("compact")
+		stripComments, _ := cmd.Flags().GetBool("strip-comments")
+		if err := formatter.ValidateMemberOrder(memberOrder); err != nil {
+			return err
+		}
+		rules, _ := cmd.Flags().GetStringArray("rewrite")
+		rewrites := []*formatter.RewriteRule{}
+		for _, rule := range rules {
+			r, err := formatter.ParseRewriteRule(rule)
+			if err != nil {
+				return err
+			}
+			rewrites = append(rewrites, r)
+		}
+		for _, f := range formatters {
+			f.SetOptions(formatter.Options{
+				Simplify:             simplify,
+				SortModifiers:        sortModifiers,
+				NormalizeAnnotations: normalizeAnnotations,
+				InlineAnnotations:    inlineAnnotations,
+				SortTriggerEvents:    sortTriggerEvents,
+				SortMembers:          sortMembers,
+				MemberOrder:          memberOrder,
+				MaxWidth:             maxWidth,
+				MaxBlankLines:        &maxBlankLines,
+				SeparateMembers:      separateMembers,
+				TrimBlocks:           trimBlocks,
+				AllmanBraces:         braceStyle == "allman",
+				KeepSingleLineGuards: keepGuards,
+				DocComments:          docComments,
+				DocCommentWidth:      docCommentWidth,
+				AddDocStubs:          addDocStubs,
+				RemoveUnusedLocals:   removeUnusedLocals,
+				Compact:              compact,
+				StripComments:        stripComments,
+				Rewrites:             rewrites,
+			})
 		}
+		if format != "text" {
+			return reportChanges(formatters, format, check)
+		}
+		differs := false
 		for _, f := range formatters {
 			err := f.Format()
+			var syntaxErrors *formatter.SyntaxErrors
+			if errors.As(err, &syntaxErrors) {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to format file %s: %s\n", f.SourceName(), err.Error())
 				os.Exit(1)
@@ -70,7 +189,16 @@ var RootCmd = &cobra.Command{
 				if changed {
 					fmt.Println(f.SourceName())
 				}
-			} else if !write {
+			}
+			if showDiff {
+				out, err := f.Formatted()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to get formatted source %s: %s\n", f.SourceName(), err.Error())
+					os.Exit(1)
+				}
+				fmt.Print(diff.Unified(f.SourceName()+".orig", f.SourceName(), f.Source(), []byte(out)))
+			}
+			if !list && !showDiff && !check && !write {
 				out, err := f.Formatted()
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Failed to get formatted source %s: %s\n", f.SourceName(), err.Error())
@@ -83,6 +211,7 @@ var RootCmd = &cobra.Command{
 				fmt.Fprintf(os.Stderr, "Failed to check file for changes %s: %s\n", f.SourceName(), err.Error())
 				os.Exit(1)
 			}
+			differs = differs || changed
 			if write && changed {
 				err = f.Write()
 				if err != nil {
@@ -91,27 +220,76 @@ var RootCmd = &cobra.Command{
 				}
 			}
 		}
+		if check && differs {
+			os.Exit(1)
+		}
 		return nil
 	},
 	DisableFlagsInUseLine: true,
 }
 
+// reportChanges writes an entry for each file that would be reformatted or
+// that cannot be parsed.  It exits with a non-zero status on syntax errors,
+// or if check is set and any file would be reformatted.
+func reportChanges(formatters []sourceFormatter, format string, check bool) error {
+	entries := []report.Entry{}
+	failed := false
+	for _, f := range formatters {
+		err := f.Format()
+		var syntaxErrors *formatter.SyntaxErrors
+		if errors.As(err, &syntaxErrors) {
+			failed = true
+			for _, e := range syntaxErrors.Errors {
+				entries = append(entries, report.Entry{
+					File:    f.SourceName(),
+					Line:    e.Line,
+					Column:  e.Column + 1,
+					Rule:    report.SyntaxErrorRule,
+					Message: e.Message,
+				})
+			}
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to format file %s: %s\n", f.SourceName(), err.Error())
+			os.Exit(1)
+		}
+		changed, err := f.Changed()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to check file for changes %s: %s\n", f.SourceName(), err.Error())
+			os.Exit(1)
+		}
+		if changed {
+			failed = failed || check
+			entries = append(entries, report.Entry{
+				File:    f.SourceName(),
+				Line:    1,
+				Column:  1,
+				Rule:    report.FormattingRule,
+				Message: "formatting differs from apexfmt's",
+			})
+		}
+	}
+	if err := report.Write(os.Stdout, format, entries); err != nil {
+		return err
+	}
+	if failed {
+		os.Exit(1)
+	}
+	return nil
+}
+
 func globalConfig() {
 }
 
-func formatSOQL() {
-	f := formatter.NewSOQLFormatter()
-	err := f.Format()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to format query: %s\n", err.Error())
-		os.Exit(1)
-	}
-	out, err := f.Formatted()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to get formatted query: %s\n", err.Error())
-		os.Exit(1)
+// isQueryFile reports whether filename holds a standalone SOQL query or SOSL
+// search, such as those saved by Data Loader or the SOQL Builder.
+func isQueryFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".soql", ".sosl":
+		return true
 	}
-	fmt.Println(out)
+	return false
 }
 
 func Execute() {
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/octoberswimmer/apexfmt/semdiff"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	semdiffCmd.Flags().Bool("json", false, "output changes as JSON")
+	RootCmd.AddCommand(semdiffCmd)
+}
+
+var semdiffCmd = &cobra.Command{
+	Use:   "semdiff old new",
+	Short: "Report changes between two Apex files, ignoring formatting and comments",
+	Long: `Report changes between two Apex files, ignoring formatting and comments.
+
+Added, removed and changed members are listed, with the statements that
+changed within each method.  semdiff exits with status 1 if there are any
+changes, and 0 if the files differ only in formatting or comments.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sources := make([]*semdiff.Source, 2)
+		for i, filename := range args {
+			s, err := semdiff.Parse(filename, nil)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(2)
+			}
+			sources[i] = s
+		}
+		changes := semdiff.Diff(sources[0], sources[1])
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			if changes == nil {
+				changes = []*semdiff.Change{}
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(changes); err != nil {
+				return err
+			}
+		} else if err := semdiff.Write(os.Stdout, changes); err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
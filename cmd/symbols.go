@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/octoberswimmer/apexfmt/symbols"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	symbolsCmd.Flags().Bool("json", false, "output symbols as JSON")
+	RootCmd.AddCommand(symbolsCmd)
+}
+
+type fileSymbols struct {
+	File    string          `json:"file"`
+	Symbols *symbols.Symbol `json:"symbols"`
+}
+
+var symbolsCmd = &cobra.Command{
+	Use:   "symbols [file...]",
+	Short: "Print an outline of the types and members in Apex",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		files := []fileSymbols{}
+		parse := func(filename string, name string) {
+			var s *symbols.Symbol
+			var err error
+			if filename == "" {
+				s, err = symbols.Parse("", os.Stdin)
+			} else {
+				s, err = symbols.Parse(filename, nil)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				os.Exit(1)
+			}
+			files = append(files, fileSymbols{File: name, Symbols: s})
+		}
+		for _, filename := range args {
+			parse(filename, filename)
+		}
+		if len(args) == 0 {
+			parse("", "<stdin>")
+		}
+		if asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(files)
+		}
+		for _, f := range files {
+			if len(files) > 1 {
+				fmt.Printf("%s:\n", f.File)
+			}
+			if err := symbols.Write(os.Stdout, f.Symbols); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
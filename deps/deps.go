@@ -0,0 +1,305 @@
+// Package deps builds a graph of the references between Apex classes,
+// interfaces, enums and triggers.
+package deps
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/ast"
+)
+
+// Reference kinds.
+const (
+	Extends    = "extends"
+	Implements = "implements"
+	New        = "new"
+	Static     = "static"
+	TypeRef    = "type"
+)
+
+// Node is a top-level type or trigger.
+type Node struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	File string `json:"file"`
+}
+
+// Edge is a dependency of one node on another.  Kinds lists how From refers
+// to To.
+type Edge struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Kinds []string `json:"kinds"`
+}
+
+// Graph is the dependency graph of a set of files.  Only references to
+// types declared in the files are included; references to inner types are
+// attributed to the top-level type that declares them.
+type Graph struct {
+	Nodes  []*Node    `json:"nodes"`
+	Edges  []*Edge    `json:"edges"`
+	Cycles [][]string `json:"cycles"`
+}
+
+// Files returns the .cls and .trigger files in paths, searching directories
+// recursively.  Files named explicitly are returned regardless of their
+// extension.
+func Files(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".cls", ".trigger":
+				if !d.IsDir() {
+					files = append(files, p)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Build parses files and returns their dependency graph.
+func Build(files []string) (*Graph, error) {
+	parsed := make([]*ast.File, len(files))
+	for i, filename := range files {
+		f, err := ast.Parse(filename, nil)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = f
+	}
+	return build(files, parsed), nil
+}
+
+func build(files []string, parsed []*ast.File) *Graph {
+	g := &Graph{Nodes: []*Node{}, Edges: []*Edge{}, Cycles: [][]string{}}
+	names := make(map[string]string)
+	for i, f := range parsed {
+		if node := declaredNode(f.Decl, files[i]); node != nil {
+			g.Nodes = append(g.Nodes, node)
+			names[strings.ToLower(node.Name)] = node.Name
+		}
+	}
+	edges := make(map[[2]string]map[string]struct{})
+	for i, f := range parsed {
+		from := declaredNode(f.Decl, files[i])
+		if from == nil {
+			continue
+		}
+		add := func(name string, kind string) {
+			// Outer.Inner refers to Outer
+			name, _, _ = strings.Cut(name, ".")
+			to, ok := names[strings.ToLower(name)]
+			if !ok || to == from.Name {
+				return
+			}
+			key := [2]string{from.Name, to}
+			if edges[key] == nil {
+				edges[key] = make(map[string]struct{})
+			}
+			edges[key][kind] = struct{}{}
+		}
+		references(f.Decl, add)
+	}
+	for key, kinds := range edges {
+		e := &Edge{From: key[0], To: key[1]}
+		for k := range kinds {
+			e.Kinds = append(e.Kinds, k)
+		}
+		sort.Strings(e.Kinds)
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Name < g.Nodes[j].Name })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+	g.Cycles = g.cycles()
+	return g
+}
+
+func declaredNode(d ast.Decl, file string) *Node {
+	switch d := d.(type) {
+	case *ast.ClassDecl:
+		return &Node{Name: d.Name, Kind: "class", File: file}
+	case *ast.InterfaceDecl:
+		return &Node{Name: d.Name, Kind: "interface", File: file}
+	case *ast.EnumDecl:
+		return &Node{Name: d.Name, Kind: "enum", File: file}
+	case *ast.TriggerDecl:
+		return &Node{Name: d.Name, Kind: "trigger", File: file}
+	}
+	return nil
+}
+
+var typeName = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*`)
+
+// references calls add for each type name referenced in d.  A receiver such
+// as `account` in `account.Name` is only a type name if no variable of that
+// name, ignoring case, is in scope.
+func references(d ast.Decl, add func(name, kind string)) {
+	seen := make(map[*ast.TypeRef]struct{})
+	types := func(t *ast.TypeRef, kind string) {
+		if t == nil {
+			return
+		}
+		if _, ok := seen[t]; ok {
+			return
+		}
+		seen[t] = struct{}{}
+		// each name in a parameterized type, e.g. Map<Id, List<Foo>>
+		for _, name := range typeName.FindAllString(t.Name, -1) {
+			add(name, kind)
+		}
+	}
+	var visit func(root ast.Node, s *scope)
+	visit = func(root ast.Node, s *scope) {
+		ast.Inspect(root, func(n ast.Node) bool {
+			if n != root {
+				if names := declaredNames(n); names != nil {
+					visit(n, &scope{names: names, parent: s})
+					return false
+				}
+			}
+			switch n := n.(type) {
+			case *ast.ClassDecl:
+				types(n.Extends, Extends)
+				for _, t := range n.Implements {
+					types(t, Implements)
+				}
+			case *ast.InterfaceDecl:
+				for _, t := range n.Extends {
+					types(t, Extends)
+				}
+			case *ast.NewExpr:
+				types(n.Type, New)
+			case *ast.CallExpr:
+				if name := qualifier(n.Recv); name != "" && !s.declares(name) {
+					add(name, Static)
+				}
+			case *ast.SelectorExpr:
+				if name := qualifier(n.X); name != "" && !s.declares(name) {
+					add(name, Static)
+				}
+			case *ast.CatchClause:
+				add(n.Type, TypeRef)
+			case *ast.TypeRef:
+				types(n, TypeRef)
+			}
+			return true
+		})
+	}
+	visit(d, &scope{names: declaredNames(d)})
+}
+
+// scope holds the lower-case names of the variables declared by a node.
+type scope struct {
+	names  map[string]struct{}
+	parent *scope
+}
+
+func (s *scope) declares(name string) bool {
+	for ; s != nil; s = s.parent {
+		if _, ok := s.names[strings.ToLower(name)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// declaredNames returns the names of the variables that n declares for its
+// children: fields and properties of a class, parameters of a method, local
+// variables of a block, and loop and catch variables.  It returns nil if n
+// does not introduce a scope.
+func declaredNames(n ast.Node) map[string]struct{} {
+	names := make(map[string]struct{})
+	declare := func(name string) {
+		names[strings.ToLower(name)] = struct{}{}
+	}
+	locals := func(stmts []ast.Stmt) {
+		for _, stmt := range stmts {
+			if l, ok := stmt.(*ast.LocalVarStmt); ok {
+				for _, v := range l.Vars {
+					declare(v.Name)
+				}
+			}
+		}
+	}
+	switch n := n.(type) {
+	case *ast.ClassDecl:
+		for _, m := range n.Members {
+			switch m := m.(type) {
+			case *ast.FieldDecl:
+				for _, v := range m.Vars {
+					declare(v.Name)
+				}
+			case *ast.PropertyDecl:
+				declare(m.Name)
+			}
+		}
+	case *ast.TriggerDecl:
+		locals(n.Body)
+	case *ast.MethodDecl:
+		for _, p := range n.Params {
+			declare(p.Name)
+		}
+	case *ast.ConstructorDecl:
+		for _, p := range n.Params {
+			declare(p.Name)
+		}
+	case *ast.Block:
+		locals(n.Stmts)
+	case *ast.ForStmt:
+		if l, ok := n.Init.(*ast.LocalVarStmt); ok {
+			for _, v := range l.Vars {
+				declare(v.Name)
+			}
+		}
+	case *ast.ForEachStmt:
+		declare(n.Var)
+	case *ast.CatchClause:
+		declare(n.Name)
+	case *ast.WhenClause:
+		if n.Var != "" {
+			declare(n.Var)
+		}
+	default:
+		return nil
+	}
+	return names
+}
+
+// qualifier returns the name of the receiver of a call or field access if it
+// may be a type name.
+func qualifier(x ast.Expr) string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.SelectorExpr:
+		return qualifier(x.X)
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package deps
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	sources := map[string]string{
+		"classes/A.cls": `public class A extends Base implements Shape {
+	private B helper = new B();
+	public List<Color> colors;
+	public void run(C c, Color color) {
+		B.go();
+		Integer n = Util.MAX;
+		color.name();
+	}
+}`,
+		"classes/B.cls":           `public class B { public static void go() { A a = null; } }`,
+		"classes/Base.cls":        `public abstract class Base {}`,
+		"classes/Shape.cls":       `public interface Shape {}`,
+		"classes/Color.cls":       `public enum Color { RED }`,
+		"classes/C.cls":           `public class C { public class Inner {} }`,
+		"classes/Util.cls":        `public class Util { public static final Integer MAX = 1; }`,
+		"classes/D.cls":           `public class D { void a() { Util util = null; } void b() { Util.reset(); } }`,
+		"classes/README.md":       `not apex`,
+		"triggers/T.trigger":      `trigger T on Account (before insert) { C.Inner i = new C.Inner(); }`,
+		"triggers/T.trigger-meta": `<xml/>`,
+	}
+	for name, src := range sources {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(src), 0644)
+	}
+	files, err := Files([]string{dir})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(files) != 9 {
+		t.Fatalf("expected 9 files, got %v", files)
+	}
+	g, err := Build(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	edges := map[string][]string{}
+	for _, e := range g.Edges {
+		edges[e.From+" -> "+e.To] = e.Kinds
+	}
+	expected := map[string][]string{
+		"A -> B":     {"new", "static", "type"},
+		"A -> Base":  {"extends"},
+		"A -> C":     {"type"},
+		"A -> Color": {"type"},
+		"A -> Shape": {"implements"},
+		"A -> Util":  {"static"},
+		"B -> A":     {"type"},
+		"D -> Util":  {"static", "type"},
+		"T -> C":     {"new", "type"},
+	}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("unexpected edges.  expected:\n%v\ngot:\n%v", expected, edges)
+	}
+	if !reflect.DeepEqual(g.Cycles, [][]string{{"A", "B"}}) {
+		t.Errorf("unexpected cycles: %v", g.Cycles)
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, "mermaid", &Graph{
+		Nodes:  []*Node{{Name: "A", Kind: "class"}, {Name: "I", Kind: "interface"}},
+		Edges:  []*Edge{{From: "A", To: "I", Kinds: []string{"implements"}}},
+		Cycles: [][]string{},
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	mermaid := "flowchart LR\n\tA[A]\n\tI([I])\n\tA -->|implements| I\n"
+	if out.String() != mermaid {
+		t.Errorf("unexpected mermaid.  expected:\n%s\ngot:\n%s", mermaid, out.String())
+	}
+}
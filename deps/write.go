@@ -0,0 +1,161 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{"dot", "json", "mermaid"}
+
+// Valid reports whether format is one of Formats.
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// cycles returns the strongly connected components of the graph with more
+// than one node, each sorted by name.
+func (g *Graph) cycles() [][]string {
+	out := make(map[string][]string)
+	for _, e := range g.Edges {
+		out[e.From] = append(out[e.From], e.To)
+	}
+	// Tarjan's algorithm
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	cycles := [][]string{}
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range out[v] {
+			if _, ok := index[w]; !ok {
+				connect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+		var component []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, n := range g.Nodes {
+		if _, ok := index[n.Name]; !ok {
+			connect(n.Name)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// inCycle reports whether both ends of e belong to the same cycle.
+func (g *Graph) inCycle(e *Edge) bool {
+	for _, c := range g.Cycles {
+		from, to := false, false
+		for _, n := range c {
+			from = from || n == e.From
+			to = to || n == e.To
+		}
+		if from && to {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the graph to w as Graphviz DOT, JSON or a Mermaid flowchart.
+// Edges within cycles are highlighted in DOT and Mermaid output.
+func Write(w io.Writer, format string, g *Graph) error {
+	switch format {
+	case "dot":
+		return writeDOT(w, g)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	case "mermaid":
+		return writeMermaid(w, g)
+	}
+	return fmt.Errorf("Unsupported format: %s", format)
+}
+
+var dotShapes = map[string]string{
+	"class":     "box",
+	"interface": "ellipse",
+	"enum":      "octagon",
+	"trigger":   "cds",
+}
+
+func writeDOT(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph deps {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "\t%q [shape=%s];\n", n.Name, dotShapes[n.Kind])
+	}
+	for _, e := range g.Edges {
+		attrs := fmt.Sprintf("label=%q", strings.Join(e.Kinds, ","))
+		if g.inCycle(e) {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "\t%q -> %q [%s];\n", e.From, e.To, attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMermaid(w io.Writer, g *Graph) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, n := range g.Nodes {
+		switch n.Kind {
+		case "interface":
+			fmt.Fprintf(&b, "\t%s([%s])\n", n.Name, n.Name)
+		case "enum":
+			fmt.Fprintf(&b, "\t%s{{%s}}\n", n.Name, n.Name)
+		case "trigger":
+			fmt.Fprintf(&b, "\t%s[/%s/]\n", n.Name, n.Name)
+		default:
+			fmt.Fprintf(&b, "\t%s[%s]\n", n.Name, n.Name)
+		}
+	}
+	var cyclic []string
+	for i, e := range g.Edges {
+		fmt.Fprintf(&b, "\t%s -->|%s| %s\n", e.From, strings.Join(e.Kinds, ","), e.To)
+		if g.inCycle(e) {
+			cyclic = append(cyclic, fmt.Sprint(i))
+		}
+	}
+	if len(cyclic) > 0 {
+		fmt.Fprintf(&b, "\tlinkStyle %s stroke:red\n", strings.Join(cyclic, ","))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
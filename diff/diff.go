@@ -0,0 +1,145 @@
+// Package diff computes line-based unified diffs, such as those printed by
+// apexfmt -d.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	// a and b are the indexes of the line's position in the old and new text
+	a, b int
+}
+
+// Unified returns the differences between old and new as a unified diff
+// with three lines of context, or "" if they are the same.
+func Unified(oldName, newName string, old, new []byte) string {
+	ops := edits(lines(string(old)), lines(string(new)))
+	var b strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		start := max(0, i-context)
+		end := i
+		for j := i; j < len(ops) && j-end <= 2*context; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+		stop := min(len(ops), end+context+1)
+		writeHunk(&b, ops[start:stop])
+		i = stop
+	}
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, ops []op) {
+	oldLen, newLen := 0, 0
+	for _, o := range ops {
+		if o.kind != '+' {
+			oldLen++
+		}
+		if o.kind != '-' {
+			newLen++
+		}
+	}
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(ops[0].a, oldLen), hunkRange(ops[0].b, newLen))
+	for _, o := range ops {
+		b.WriteByte(o.kind)
+		b.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the 1-based range of n lines starting at index start.
+// An empty range is identified by the line before it.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// lines splits s into lines, keeping their line endings.
+func lines(s string) []string {
+	l := strings.SplitAfter(s, "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+	return l
+}
+
+// edits returns a shortest edit script turning a into b, using Myers'
+// algorithm.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var ops []op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{' ', a[x], x, y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, op{'+', b[y], x, y})
+		} else {
+			x--
+			ops = append(ops, op{'-', a[x], x, y})
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,59 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests :=
+		[]struct {
+			old    string
+			new    string
+			output string
+		}{
+			{
+				"a\nb\n",
+				"a\nb\n",
+				""},
+			{
+				"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n",
+				"1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n16\n",
+				`--- a.cls
++++ b.cls
+@@ -1,5 +1,5 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
+@@ -12,5 +12,4 @@
+ 12
+ 13
+ 14
+-15
+ 16
+`},
+			{
+				"x",
+				"",
+				`--- a.cls
++++ b.cls
+@@ -1,1 +0,0 @@
+-x
+\ No newline at end of file
+`},
+			{
+				"",
+				"SELECT Id FROM Account\n",
+				`--- a.cls
++++ b.cls
+@@ -0,0 +1,1 @@
++SELECT Id FROM Account
+`},
+		}
+	for _, tt := range tests {
+		out := Unified("a.cls", "b.cls", []byte(tt.old), []byte(tt.new))
+		if out != tt.output {
+			t.Errorf("unexpected diff.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// DefaultDocCommentWidth is the width ApexDoc comments are reflowed to when
+// Options.DocCommentWidth is not set.
+const DefaultDocCommentWidth = 80
+
+// apexDocTagOrder is the order of tags in a formatted ApexDoc comment.  Tags
+// not listed come after @throws and before @example.
+var apexDocTagOrder = map[string]int{
+	"@description": 0,
+	"@param":       1,
+	"@return":      2,
+	"@returns":     2,
+	"@throws":      3,
+	"@exception":   3,
+	"@example":     5,
+}
+
+// apexDocTags are the tags recognized inside an @example block; other lines
+// starting with @, such as annotations, are part of the example.
+var apexDocTags = map[string]bool{
+	"@author":        true,
+	"@date":          true,
+	"@deprecated":    true,
+	"@description":   true,
+	"@example":       true,
+	"@exception":     true,
+	"@group":         true,
+	"@group-content": true,
+	"@param":         true,
+	"@return":        true,
+	"@returns":       true,
+	"@see":           true,
+	"@since":         true,
+	"@throws":        true,
+}
+
+// DocComment is an ApexDoc comment with its gutter removed, split into the
+// description and the tags that follow it.
+type DocComment struct {
+	Description []string
+	Tags        []*DocTag
+}
+
+// DocTag is a tag in an ApexDoc comment, such as @param, and its text.
+type DocTag struct {
+	// Name is the lower-case tag, including the @.
+	Name string
+	// Lines are the lines of the tag's text, starting with the text on the
+	// tag's line if any.
+	Lines []string
+}
+
+// ParseDocComment parses the text of an ApexDoc comment, including the
+// surrounding /** and */.
+func ParseDocComment(text string) *DocComment {
+	body := strings.TrimSuffix(strings.TrimPrefix(text, "/**"), "*/")
+	c := &DocComment{}
+	var current *DocTag
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimRight(stripGutter(line), " \t")
+		if name := docTagName(line); name != "" && (current == nil || current.Name != "@example" || apexDocTags[strings.ToLower(name)]) {
+			current = &DocTag{Name: strings.ToLower(name)}
+			c.Tags = append(c.Tags, current)
+			line = strings.TrimSpace(strings.TrimPrefix(line, name))
+			if line == "" {
+				continue
+			}
+		}
+		if current == nil {
+			c.Description = append(c.Description, line)
+		} else {
+			current.Lines = append(current.Lines, line)
+		}
+	}
+	return c
+}
+
+// Text returns the tag's text on one line.
+func (t *DocTag) Text() string {
+	return strings.Join(strings.Fields(strings.Join(t.Lines, " ")), " ")
+}
+
+// Param splits the text of a tag such as @param or @throws into the name it
+// documents and its description.
+func (t *DocTag) Param() (string, string) {
+	return splitWord(t.Text())
+}
+
+// Verbatim returns the tag's lines without leading and trailing blank lines,
+// as used for @example.
+func (t *DocTag) Verbatim() []string {
+	return trimBlankLines(t.Lines)
+}
+
+func tagRank(name string) int {
+	if r, ok := apexDocTagOrder[name]; ok {
+		return r
+	}
+	return 4
+}
+
+// formatComment formats a comment token.  ApexDoc comments are reformatted
+// if Options.DocComments is set; other comments only have their indentation
+// removed so they can be re-indented.
+func (v *FormatVisitor) formatComment(text string) string {
+	if v.options.DocComments && IsDocComment(text) && strings.Contains(text, "\n") {
+		width := v.options.DocCommentWidth
+		if width <= 0 {
+			width = DefaultDocCommentWidth
+		}
+		return formatApexDoc(text, width)
+	}
+	return cleanWhitespace(text)
+}
+
+// formatApexDoc normalizes the leading ` * ` gutter of an ApexDoc comment,
+// reflows text to width, aligns @param descriptions, and orders tags.
+// @example blocks are left verbatim.
+func formatApexDoc(text string, width int) string {
+	c := ParseDocComment(text)
+	tags := c.Tags
+	sortTags(tags)
+
+	paramWidth := 0
+	for _, t := range tags {
+		if t.Name == "@param" {
+			name, _ := t.Param()
+			paramWidth = max(paramWidth, len("@param ")+len(name))
+		}
+	}
+
+	out := []string{}
+	out = append(out, reflowParagraphs(c.Description, width)...)
+	if len(out) > 0 && len(tags) > 0 {
+		out = append(out, "")
+	}
+	for _, t := range tags {
+		switch t.Name {
+		case "@example":
+			out = append(out, t.Name)
+			out = append(out, t.Verbatim()...)
+		case "@param":
+			name, desc := t.Param()
+			prefix := "@param " + name
+			prefix += strings.Repeat(" ", paramWidth-len(prefix))
+			out = append(out, hangingIndent(prefix, desc, width)...)
+		default:
+			out = append(out, hangingIndent(t.Name, t.Text(), width)...)
+		}
+	}
+	out = trimBlankLines(out)
+
+	var doc strings.Builder
+	doc.WriteString("/**\n")
+	for _, line := range out {
+		if line == "" {
+			doc.WriteString(" *\n")
+		} else {
+			doc.WriteString(" * " + line + "\n")
+		}
+	}
+	doc.WriteString(" */")
+	return doc.String()
+}
+
+// stripGutter removes indentation and the leading `*` from a comment line,
+// keeping indentation beyond the single space after the `*`.
+func stripGutter(line string) string {
+	line = strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(line, "*") {
+		line = strings.TrimPrefix(line, "*")
+		line = strings.TrimPrefix(line, " ")
+	}
+	return line
+}
+
+func docTagName(line string) string {
+	if !strings.HasPrefix(line, "@") {
+		return ""
+	}
+	name, _ := splitWord(line)
+	return name
+}
+
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+func sortTags(tags []*DocTag) {
+	// insertion sort keeps tags of equal rank in source order
+	for i := 1; i < len(tags); i++ {
+		for j := i; j > 0 && tagRank(tags[j].Name) < tagRank(tags[j-1].Name); j-- {
+			tags[j], tags[j-1] = tags[j-1], tags[j]
+		}
+	}
+}
+
+// reflowParagraphs wraps lines to width, keeping blank lines between
+// paragraphs.
+func reflowParagraphs(lines []string, width int) []string {
+	out := []string{}
+	paragraph := []string{}
+	flush := func() {
+		if len(paragraph) > 0 {
+			out = append(out, wrapWords(strings.Fields(strings.Join(paragraph, " ")), width)...)
+			paragraph = nil
+		}
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			flush()
+			out = append(out, "")
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flush()
+	return trimBlankLines(out)
+}
+
+// hangingIndent wraps text after prefix to width, indenting continuation
+// lines to line up with the first word of text.
+func hangingIndent(prefix, text string, width int) []string {
+	if text == "" {
+		return []string{strings.TrimRight(prefix, " ")}
+	}
+	lines := wrapWords(strings.Fields(text), width-len(prefix)-1)
+	for i := range lines {
+		if i == 0 {
+			lines[i] = prefix + " " + lines[i]
+		} else {
+			lines[i] = strings.Repeat(" ", len(prefix)+1) + lines[i]
+		}
+	}
+	return lines
+}
+
+func wrapWords(words []string, width int) []string {
+	lines := []string{}
+	var line strings.Builder
+	for _, w := range words {
+		if line.Len() > 0 && line.Len()+1+len(w) > width {
+			lines = append(lines, line.String())
+			line.Reset()
+		}
+		if line.Len() > 0 {
+			line.WriteString(" ")
+		}
+		line.WriteString(w)
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// AccessModifier returns the access modifier in modifiers, treating
+// webservice as global, or "" if there is none.
+func AccessModifier(modifiers []parser.IModifierContext) string {
+	for _, m := range modifiers {
+		switch {
+		case m.GLOBAL() != nil, m.WEBSERVICE() != nil:
+			return "global"
+		case m.PUBLIC() != nil:
+			return "public"
+		case m.PROTECTED() != nil:
+			return "protected"
+		case m.PRIVATE() != nil:
+			return "private"
+		}
+	}
+	return ""
+}
+
+// HasDocComment reports whether an ApexDoc comment precedes ctx.
+func HasDocComment(tokens *antlr.CommonTokenStream, ctx antlr.ParserRuleContext) bool {
+	for _, c := range tokens.GetHiddenTokensToLeft(ctx.GetStart().GetTokenIndex(), COMMENTS_CHANNEL) {
+		if IsDocComment(c.GetText()) {
+			return true
+		}
+	}
+	return false
+}
+
+// withDocStub prefixes a formatted declaration with an ApexDoc template if
+// Options.AddDocStubs is set and the global or public declaration has no
+// ApexDoc comment.  Parameters get a @param tag each, and @return is added
+// for non-void methods.
+func (v *FormatVisitor) withDocStub(ctx antlr.ParserRuleContext, params parser.IFormalParametersContext, returns bool, declaration string) string {
+	if !v.options.AddDocStubs {
+		return declaration
+	}
+	if access := declarationAccess(ctx); (access != "global" && access != "public") || HasDocComment(v.tokens, ctx) {
+		return declaration
+	}
+	var stub strings.Builder
+	stub.WriteString("/**\n * @description\n")
+	if params != nil && params.FormalParameterList() != nil {
+		for _, p := range params.FormalParameterList().AllFormalParameter() {
+			stub.WriteString(" * @param " + p.Id().GetText() + "\n")
+		}
+	}
+	if returns {
+		stub.WriteString(" * @return\n")
+	}
+	stub.WriteString(" */")
+	trimmed := strings.TrimLeft(declaration, "\n")
+	return declaration[:len(declaration)-len(trimmed)] + v.verbatim(stub.String()) + "\n" + trimmed
+}
+
+// InterfaceAccess returns the access modifier of the interface declaring
+// ctx, which interface methods share.
+func InterfaceAccess(ctx *parser.InterfaceMethodDeclarationContext) string {
+	switch p := ctx.GetParent().GetParent().GetParent().(type) {
+	case *parser.TypeDeclarationContext:
+		return AccessModifier(p.AllModifier())
+	case *parser.MemberDeclarationContext:
+		switch d := p.GetParent().(type) {
+		case *parser.ClassBodyDeclarationContext:
+			return AccessModifier(d.AllModifier())
+		case *parser.BlockMemberDeclarationContext:
+			return AccessModifier(d.AllModifier())
+		}
+	}
+	return ""
+}
+
+// declarationAccess returns the access modifier of a declaration that can
+// take an ApexDoc stub.
+func declarationAccess(ctx antlr.ParserRuleContext) string {
+	switch c := ctx.(type) {
+	case *parser.TypeDeclarationContext:
+		return AccessModifier(c.AllModifier())
+	case *parser.ClassBodyDeclarationContext:
+		return AccessModifier(c.AllModifier())
+	case *parser.InterfaceMethodDeclarationContext:
+		return InterfaceAccess(c)
+	}
+	return ""
+}
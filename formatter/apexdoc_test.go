@@ -0,0 +1,202 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestFormatApexDoc(t *testing.T) {
+	tests :=
+		[]struct {
+			input  string
+			output string
+		}{
+			{
+				"/**\n    * Adds two numbers.\n  */",
+				"/**\n * Adds two numbers.\n */",
+			},
+			{
+				`/**
+	 * @return the sum
+	 * @param a the first number to add, which may be negative or zero
+	 * @param total the second number
+	 * @description Adds two
+	 * numbers together.
+	 */`,
+				`/**
+ * @description Adds two numbers together.
+ * @param a     the first number to add, which may be
+ *              negative or zero
+ * @param total the second number
+ * @return the sum
+ */`,
+			},
+			{
+				`/** Formats
+ * records.
+ *
+ * @example
+ * @IsTest
+ * static void formats() {
+ *     Foo.format(records);
+ * }
+ * @throws FooException when records is null
+ */`,
+				`/**
+ * Formats records.
+ *
+ * @throws FooException when records is null
+ * @example
+ * @IsTest
+ * static void formats() {
+ *     Foo.format(records);
+ * }
+ */`,
+			},
+		}
+	for _, tt := range tests {
+		out := formatApexDoc(tt.input, 50)
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{},
+				`public class Foo {
+    /**
+      * Does the thing
+      */
+    public void run() {}
+}`,
+				`public class Foo {
+	/**
+	      * Does the thing
+	      */
+	public void run() {}
+}`},
+			{
+				Options{DocComments: true},
+				`public class Foo {
+    /**
+      * Does the thing
+      */
+    public void run() {}
+    /* not
+       ApexDoc */
+    public void stop() {}
+}`,
+				`public class Foo {
+	/**
+	 * Does the thing
+	 */
+	public void run() {}
+	/* not
+	       ApexDoc */
+	public void stop() {}
+}`},
+			{
+				Options{AddDocStubs: true},
+				`public class Foo {
+	/** Documented */
+	public void run() {}
+
+	@AuraEnabled
+	public static String label(Id recordId, String field) { return null; }
+	private void helper() {}
+	// inner type
+	global class Inner {}
+	public interface Shape { Decimal area(); }
+}`,
+				`/**
+ * @description
+ */
+public class Foo {
+	/** Documented */
+	public void run() {}
+
+	/**
+	 * @description
+	 * @param recordId
+	 * @param field
+	 * @return
+	 */
+	@AuraEnabled
+	public static String label(Id recordId, String field) {
+		return null;
+	}
+	private void helper() {}
+	// inner type
+	/**
+	 * @description
+	 */
+	global class Inner {}
+	/**
+	 * @description
+	 */
+	public interface Shape {
+		/**
+		 * @description
+		 * @return
+		 */
+		Decimal area();
+	}
+}`},
+			{
+				Options{},
+				`trigger T on Account (before insert) { public interface Greeter { String greet(); } System.debug(1); }`,
+				`trigger T on Account (before insert) {
+	public interface Greeter {
+		String greet();
+	}
+	System.debug(1);
+}`},
+			{
+				Options{AddDocStubs: true},
+				`trigger T on Account (before insert) { public interface Greeter { String greet(); } }`,
+				`trigger T on Account (before insert) {
+	public interface Greeter {
+		/**
+		 * @description
+		 * @return
+		 */
+		String greet();
+	}
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
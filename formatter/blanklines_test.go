@@ -0,0 +1,108 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestBlankLines(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	input := `public class Foo {
+	Integer x;
+	Integer y;
+	public void a() {
+
+		x = 1;
+
+
+
+		y = 2;
+
+	}
+	public void b() {}
+	// property
+	public String name {get; set;}
+}`
+	tests :=
+		[]struct {
+			options Options
+			output  string
+		}{
+			{
+				Options{},
+				`public class Foo {
+	Integer x;
+	Integer y;
+	public void a() {
+
+		x = 1;
+
+		y = 2;
+	}
+	public void b() {}
+	// property
+	public String name {get; set;}
+}`},
+			{
+				Options{MaxBlankLines: blankLines(2), TrimBlocks: true},
+				`public class Foo {
+	Integer x;
+	Integer y;
+	public void a() {
+		x = 1;
+
+
+		y = 2;
+	}
+	public void b() {}
+	// property
+	public String name {get; set;}
+}`},
+			{
+				Options{MaxBlankLines: blankLines(0), SeparateMembers: true},
+				`public class Foo {
+	Integer x;
+	Integer y;
+
+	public void a() {
+		x = 1;
+		y = 2;
+	}
+
+	public void b() {}
+
+	// property
+	public String name {get; set;}
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
+
+func blankLines(n int) *int {
+	return &n
+}
@@ -0,0 +1,32 @@
+package formatter
+
+import (
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// declarationBody returns a formatted class, constructor or method body,
+// including the separator before its opening brace.
+func (v *FormatVisitor) declarationBody(body string) string {
+	if !v.options.AllmanBraces {
+		return " " + body
+	}
+	if body == "{}" {
+		return "\n{\n}"
+	}
+	return "\n" + body
+}
+
+// isSingleLineGuard reports whether an if statement is a brace-less guard
+// clause, like `if (x) return;`, written on a single line and without an
+// else branch.
+func isSingleLineGuard(ctx *parser.IfStatementContext) bool {
+	if ctx.ELSE() != nil {
+		return false
+	}
+	stmt := ctx.Statement(0)
+	if stmt.ReturnStatement() == nil && stmt.ThrowStatement() == nil &&
+		stmt.BreakStatement() == nil && stmt.ContinueStatement() == nil {
+		return false
+	}
+	return ctx.GetStart().GetLine() == ctx.GetStop().GetLine()
+}
@@ -0,0 +1,99 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestBraces(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{AllmanBraces: true},
+				`public class Foo { public Foo() { init(); } public void run() { if (x) { y(); } } void empty() {} class Inner {} }`,
+				`public class Foo
+{
+	public Foo()
+	{
+		init();
+	}
+	public void run()
+	{
+		if (x) {
+			y();
+		}
+	}
+	void empty()
+	{
+	}
+	class Inner
+	{
+	}
+}`},
+			{
+				Options{KeepSingleLineGuards: true},
+				`public class Foo { public void run(Account a) {
+	if (a == null) return;
+	if (a.Name == null) throw new FooException();
+	if (a.Id == null)
+		return;
+	if (a.Id != null) update a;
+	for (Integer i = 0; i < 10; i++) {
+		if (i > 5) break;
+		if (i < 2) continue;
+		else { return; }
+	}
+} }`,
+				`public class Foo {
+	public void run(Account a) {
+		if (a == null) return;
+		if (a.Name == null) throw new FooException();
+		if (a.Id == null) {
+			return;
+		}
+		if (a.Id != null) {
+			update a;
+		}
+		for (Integer i = 0; i < 10; i++) {
+			if (i > 5) break;
+			if (i < 2) {
+				continue;
+			} else {
+				return;
+			}
+		}
+	}
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
@@ -178,3 +178,14 @@ func (v *ChainVisitor) VisitForClauses(ctx *parser.ForClausesContext) interface{
 func (v *ChainVisitor) VisitArth2Expression(ctx *parser.Arth2ExpressionContext) interface{} {
 	return 1 + v.visitRule(ctx.Expression(0)).(int) + v.visitRule(ctx.Expression(1)).(int)
 }
+
+func (v *ChainVisitor) VisitGroupByClause(ctx *parser.GroupByClauseContext) interface{} {
+	if ctx.ROLLUP() != nil || ctx.CUBE() != nil {
+		return len(ctx.AllFieldName())
+	}
+	score := v.visitRule(ctx.SelectList()).(int)
+	if having := ctx.LogicalExpression(); having != nil {
+		score += 1 + v.visitRule(having).(int)
+	}
+	return score
+}
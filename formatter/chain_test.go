@@ -69,6 +69,8 @@ WHERE
 			Location__c != null AND
 			Start__c = YESTERDAY
 		)`, 8},
+		{`SELECT LeadSource, COUNT(Name) FROM Lead GROUP BY LeadSource HAVING COUNT(Name) > 100`, 6},
+		{`SELECT LeadSource, Rating FROM Lead GROUP BY ROLLUP(LeadSource, Rating)`, 5},
 	}
 	for _, tt := range tests {
 		input := antlr.NewInputStream(tt.input)
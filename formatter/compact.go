@@ -0,0 +1,113 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Options.Compact is a layout policy: the visitors print as usual, but text
+// that must be kept as is, such as string literals and comments, has its
+// whitespace replaced by placeholders from the Unicode private use area.
+// All remaining whitespace is layout, which compactLayout reduces to a
+// single space where two tokens would otherwise run together.
+var verbatimWhitespace = strings.NewReplacer(" ", "\uE000", "\t", "\uE001", "\n", "\uE002", "\r", "\uE003")
+
+// restoreWhitespace returns the whitespace character r is a placeholder for,
+// or r itself.
+func restoreWhitespace(r rune) rune {
+	switch r {
+	case '\uE000':
+		return ' '
+	case '\uE001':
+		return '\t'
+	case '\uE002':
+		return '\n'
+	case '\uE003':
+		return '\r'
+	}
+	return r
+}
+
+// verbatim marks text to be printed as is in compact output.
+func (v *FormatVisitor) verbatim(text string) string {
+	if !v.options.Compact {
+		return text
+	}
+	return verbatimWhitespace.Replace(text)
+}
+
+// protectLiterals marks the text of string literals, which the visitors
+// print directly from their tokens, to be printed as is.
+func (v *FormatVisitor) protectLiterals() {
+	for _, t := range v.tokens.GetAllTokens() {
+		switch t.GetTokenType() {
+		case parser.ApexLexerStringLiteral, parser.ApexLexerFindLiteral, parser.ApexLexerFindLiteralAlt:
+			t.SetText(v.verbatim(t.GetText()))
+		}
+	}
+}
+
+// compactLayout removes layout whitespace from visitor output, keeping a
+// space only between characters that would otherwise form a different
+// token, and restores the whitespace of verbatim text.
+func compactLayout(out string) string {
+	var b strings.Builder
+	var prev rune
+	space := false
+	for _, r := range out {
+		switch r {
+		case ' ', '\t', '\n', '\r':
+			space = true
+			continue
+		}
+		decoded := restoreWhitespace(r)
+		if space && needsSpace(prev, decoded) {
+			b.WriteRune(' ')
+		}
+		space = false
+		b.WriteRune(decoded)
+		prev = decoded
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// needsSpace reports whether characters a and b must be separated so that
+// the tokens they end and begin are not lexed differently, e.g. `a b` as
+// `ab` or `- -` as `--`.
+func needsSpace(a, b rune) bool {
+	switch {
+	case a == 0, isSpace(a), isSpace(b):
+		return false
+	case isWordChar(a) && isWordChar(b):
+		return true
+	}
+	return mergingPairs[string([]rune{a, b})]
+}
+
+// mergingPairs are the pairs of characters that begin an operator or a
+// comment.
+var mergingPairs = map[string]bool{}
+
+func init() {
+	for _, op := range []string{
+		"++", "--", "&&", "||", "==", "!=", "<>", "<=", ">=", "<<", ">>",
+		"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "=>", "->", "?.", "??",
+		"//", "/*", "*/",
+	} {
+		mergingPairs[op] = true
+	}
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || r == '$' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= 0x80
+}
+
+// IsDocComment reports whether a comment is an ApexDoc comment.
+func IsDocComment(text string) bool {
+	return strings.HasPrefix(text, "/**") && text != "/**/"
+}
@@ -0,0 +1,112 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompact(t *testing.T) {
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{Compact: true},
+				`/** Counts things. */
+public with sharing class Foo {
+	// the count
+	private Integer count = -1; // trailing
+	public void run(List<List<Integer>> xs) {
+		Integer y = count - -1;
+		y += count++ + ++count;
+		List<Account> a = [SELECT Id, Name FROM Account WHERE Name = :s AND CreatedDate = LAST_N_DAYS:5];
+		if (y >= 1 && a != null) { System.debug(a?.size()); } else { y = y / 2; }
+	}
+}`,
+				`/** Counts things. */public with sharing class Foo{// the count
+private Integer count=-1;// trailing
+public void run(List<List<Integer>>xs){Integer y=count- -1;y+=count++ + ++count;List<Account>a=[SELECT Id,Name FROM Account WHERE Name=:s AND CreatedDate=LAST_N_DAYS:5];if(y>=1&&a!=null){System.debug(a?.size());}else{y=y/2;}}}
+`},
+			{
+				Options{Compact: true},
+				`public class Foo {
+	/**
+	 * Runs.
+	 * @param s  the   text
+	 */
+	public void run(String s) {
+		String t = 'a  b	c'; // tab inside
+		List<Account> r = [FIND 'Acme  Corp' IN ALL FIELDS RETURNING Account][0];
+		Boolean b = !(i > 0) || i != -1;
+	}
+}`,
+				`public class Foo{/**
+ * Runs.
+ * @param s  the   text
+ */public void run(String s){String t='a  b	c';// tab inside
+List<Account>r=[FIND 'Acme  Corp'IN ALL FIELDS RETURNING Account][0];Boolean b=!(i>0)||i!=-1;}}
+`},
+			{
+				Options{Compact: true, AddDocStubs: true},
+				`global class Foo { global Integer size() { return 0; } }`,
+				`/**
+ * @description
+ */global class Foo{/**
+ * @description
+ * @return
+ */global Integer size(){return 0;}}
+`},
+			{
+				Options{Compact: true, StripComments: true},
+				`/** Counts things. */
+public with sharing class Foo {
+	// the count
+	private Integer count = 1; // trailing
+	/* block */
+	public Integer next() { return count++; }
+}`,
+				`/** Counts things. */public with sharing class Foo{private Integer count=1;public Integer next(){return count++;}}
+`},
+			{
+				Options{StripComments: true},
+				`/**
+ * Counts things.
+ */
+public with sharing class Foo {
+	// the count
+	private Integer count = 1;
+}`,
+				`/**
+ * Counts things.
+ */
+public with sharing class Foo {
+	private Integer count = 1;
+}
+`},
+		}
+	for _, tt := range tests {
+		f := NewFormatter("", strings.NewReader(tt.input))
+		f.SetOptions(tt.options)
+		out, err := f.Formatted()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+		// compact output formats the same as the original
+		if !tt.options.Compact || tt.options.AddDocStubs {
+			continue
+		}
+		original := NewFormatter("", strings.NewReader(tt.input))
+		original.SetOptions(Options{StripComments: true})
+		expected, _ := original.Formatted()
+		roundTrip := NewFormatter("", strings.NewReader(out))
+		roundTrip.SetOptions(Options{StripComments: true})
+		if got, err := roundTrip.Formatted(); err != nil || got != expected {
+			t.Errorf("compact output does not round-trip.  expected:\n%s\ngot:\n%s\n", expected, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+func TestEnum(t *testing.T) {
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{},
+				`public enum Season {WINTER,SPRING,SUMMER,FALL}`,
+				`public enum Season { WINTER, SPRING, SUMMER, FALL }`},
+			{
+				Options{},
+				`public class Foo { private enum Size {SMALL, LARGE} enum Empty {} }`,
+				`public class Foo {
+	private enum Size { SMALL, LARGE }
+	enum Empty {}
+}`},
+			{
+				Options{},
+				`public enum Status {
+	// Not yet started
+	DRAFT,
+	/** Awaiting approval */
+	PENDING,
+
+	APPROVED,
+	REJECTED // terminal
+}`,
+				`public enum Status {
+	// Not yet started
+	DRAFT,
+	/** Awaiting approval */
+	PENDING,
+
+	APPROVED,
+	REJECTED
+	// terminal
+}`},
+			{
+				Options{MaxWidth: 40},
+				`public class Foo { enum Region {NORTH_AMERICA, SOUTH_AMERICA, EUROPE, ASIA} }`,
+				`public class Foo {
+	enum Region {
+		NORTH_AMERICA,
+		SOUTH_AMERICA,
+		EUROPE,
+		ASIA
+	}
+}`},
+			{
+				Options{MaxWidth: 30},
+				`public class Foo { enum Size {SMALL, MEDIUM} }`,
+				`public class Foo {
+	enum Size {
+		SMALL,
+		MEDIUM
+	}
+}`},
+			{
+				Options{MaxWidth: 30},
+				`enum Size {SMALL, MEDIUM}`,
+				`enum Size { SMALL, MEDIUM }`},
+			{
+				Options{StripComments: true},
+				`enum Level { LOW, /* mid */ MEDIUM, HIGH // top
+}`,
+				`enum Level { LOW, MEDIUM, HIGH }`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
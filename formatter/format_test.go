@@ -354,7 +354,8 @@ func TestSOQL(t *testing.T) {
 		Contact
 	WHERE
 		AccountId IN :accounts.keySet()
-	GROUP BY Account.Name
+	GROUP BY
+		Account.Name
 ]`},
 			{
 				`[ SELECT
@@ -428,6 +429,40 @@ func TestSOQL(t *testing.T) {
 	ORDER BY
 		Start__c
 ]`},
+			{
+				`[SELECT LeadSource, COUNT(Name) cnt FROM Lead GROUP BY LeadSource HAVING COUNT(Name) > 100 AND COUNT(Name) < 1000 ORDER BY LeadSource]`,
+				`[
+	SELECT
+		LeadSource,
+		COUNT(Name) cnt
+	FROM
+		Lead
+	GROUP BY
+		LeadSource
+	HAVING
+		COUNT(Name) > 100 AND
+		COUNT(Name) < 1000
+	ORDER BY
+		LeadSource
+]`},
+			{
+				`[SELECT LeadSource, Rating, COUNT(Name) cnt FROM Lead GROUP BY ROLLUP(LeadSource, Rating)]`,
+				`[
+	SELECT
+		LeadSource,
+		Rating,
+		COUNT(Name) cnt
+	FROM
+		Lead
+	GROUP BY ROLLUP(
+		LeadSource,
+		Rating
+	)
+]`},
+			{
+				`[SELECT Type FROM Account GROUP BY CUBE(Type)]`,
+				`[SELECT Type FROM Account GROUP BY CUBE(Type)]`,
+			},
 			{
 				`[SELECT Id FROM ClinicalEncounter WHERE Id = :encounters[0].Id ALL ROWS]`,
 				`[SELECT Id FROM ClinicalEncounter WHERE Id = :encounters[0].Id ALL ROWS]`,
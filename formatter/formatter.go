@@ -6,37 +6,70 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
-	"github.com/octoberswimmer/apexfmt/parser"
 )
 
 type Formatter struct {
 	filename  string
 	reader    io.Reader
+	options   Options
 	source    []byte
 	formatted []byte
 }
 
 func (f *Formatter) SourceName() string {
-	if f.filename != "" {
-		return f.filename
+	return sourceName(f.filename)
+}
+
+// SyntaxError is a parse error reported by the parser.  Column is 0-based.
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// SyntaxErrors is returned when the source cannot be parsed.
+type SyntaxErrors struct {
+	Filename string
+	Errors   []SyntaxError
+}
+
+func (e *SyntaxErrors) Error() string {
+	lines := []string{}
+	for _, s := range e.Errors {
+		line := "line " + strconv.Itoa(s.Line) + ":" + strconv.Itoa(s.Column) + " " + s.Message
+		if e.Filename != "" {
+			line = e.Filename + " " + line
+		}
+		lines = append(lines, line)
 	}
-	return "<stdin>"
+	return strings.Join(lines, "\n")
 }
 
 type errorListener struct {
 	*antlr.DefaultErrorListener
 	filename string
+	errors   []SyntaxError
 }
 
 func (e *errorListener) SyntaxError(_ antlr.Recognizer, _ interface{}, line, column int, msg string, _ antlr.RecognitionException) {
-	if e.filename == "" {
-		_, _ = fmt.Fprintln(os.Stderr, "line "+strconv.Itoa(line)+":"+strconv.Itoa(column)+" "+msg)
-	} else {
-		_, _ = fmt.Fprintln(os.Stderr, e.filename+" line "+strconv.Itoa(line)+":"+strconv.Itoa(column)+" "+msg)
+	e.errors = append(e.errors, SyntaxError{Line: line, Column: column, Message: msg})
+}
+
+// expectEOF records a syntax error if input remains after the parsed rule.
+func (e *errorListener) expectEOF(stream *antlr.CommonTokenStream) {
+	if t := stream.LT(1); t.GetTokenType() != antlr.TokenEOF {
+		e.errors = append(e.errors, SyntaxError{Line: t.GetLine(), Column: t.GetColumn(), Message: fmt.Sprintf("extraneous input '%s' expecting <EOF>", t.GetText())})
+	}
+}
+
+func (e *errorListener) err() error {
+	if len(e.errors) == 0 {
+		return nil
 	}
-	os.Exit(1)
+	return &SyntaxErrors{Filename: e.filename, Errors: e.errors}
 }
 
 func NewFormatter(filename string, reader io.Reader) *Formatter {
@@ -50,6 +83,10 @@ func NewFormatter(filename string, reader io.Reader) *Formatter {
 	}
 }
 
+func (f *Formatter) SetOptions(options Options) {
+	f.options = options
+}
+
 func (f *Formatter) Formatted() (string, error) {
 	if f.formatted == nil {
 		err := f.Format()
@@ -78,24 +115,38 @@ func (f *Formatter) Format() error {
 		}
 		f.source = src
 	}
-	input := antlr.NewInputStream(string(f.source))
-	lexer := parser.NewApexLexer(input)
-	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
-
-	p := parser.NewApexParser(stream)
-	p.RemoveErrorListeners()
-	p.AddErrorListener(&errorListener{filename: f.filename})
-	// p.AddErrorListener(antlr.NewDiagnosticErrorListener(false))
-
+	src := f.source
+	for _, r := range f.options.Rewrites {
+		rewritten, err := r.Apply(f.filename, src)
+		if err != nil {
+			return err
+		}
+		src = rewritten
+	}
+	tree, stream, err := ParseSource(f.filename, src)
+	if err != nil {
+		return err
+	}
 	v := NewFormatVisitor(stream)
-	out, ok := v.visitRule(p.CompilationUnit()).(string)
+	v.options = f.options
+	out, ok := v.visitRule(tree).(string)
 	if !ok {
 		return fmt.Errorf("Unexpected result parsing apex")
 	}
+	if f.options.Compact {
+		if _, _, err := ParseSource(f.filename, []byte(out)); err != nil {
+			return fmt.Errorf("Compact output does not parse: %w", err)
+		}
+	}
 	f.formatted = append([]byte(out), '\n')
 	return nil
 }
 
+// Source returns the source read by Format.
+func (f *Formatter) Source() []byte {
+	return f.source
+}
+
 func (f *Formatter) Write() error {
 	if f.formatted == nil {
 		return fmt.Errorf("No formatted source found")
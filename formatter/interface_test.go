@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+func TestInterface(t *testing.T) {
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{},
+				`public interface Shape extends Comparable, Sizeable { Decimal area(); }`,
+				`public interface Shape extends Comparable, Sizeable {
+	Decimal area();
+}`},
+			{
+				Options{},
+				`public interface Empty {}`,
+				`public interface Empty {}`},
+			{
+				Options{},
+				`public class Foo { interface Bar {
+} public interface Baz extends Bar { void go(); } }`,
+				`public class Foo {
+	interface Bar {}
+	public interface Baz extends Bar {
+		void go();
+	}
+}`},
+			{
+				Options{},
+				`global interface Shape {
+	// Area in square units
+	Decimal area();
+
+
+	/** Perimeter */
+	Decimal perimeter();
+	// TODO: volume
+}`,
+				`global interface Shape {
+	// Area in square units
+	Decimal area();
+
+	/** Perimeter */
+	Decimal perimeter();
+	// TODO: volume
+}`},
+			{
+				Options{MaxWidth: 60},
+				`public interface AccountService extends Database.Batchable<SObject>, Schedulable { void run(); }`,
+				`public interface AccountService extends
+		Database.Batchable<SObject>,
+		Schedulable {
+	void run();
+}`},
+			{
+				Options{MaxWidth: 60},
+				`public class Jobs { public interface AccountService extends Database.Batchable<SObject>, Schedulable { void run(); } }`,
+				`public class Jobs {
+	public interface AccountService extends
+			Database.Batchable<SObject>,
+			Schedulable {
+		void run();
+	}
+}`},
+			{
+				Options{AllmanBraces: true},
+				`public interface Shape { Decimal area(); } `,
+				`public interface Shape
+{
+	Decimal area();
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// MemberGroups lists the member groups recognized in Options.MemberOrder, in
+// the default order used by Options.SortMembers.
+var MemberGroups = []string{
+	"constants",
+	"static-fields",
+	"fields",
+	"initializers",
+	"properties",
+	"constructors",
+	"public-methods",
+	"private-methods",
+	"inner-types",
+}
+
+// ValidateMemberOrder returns an error if order includes an unknown group.
+func ValidateMemberOrder(order []string) error {
+	for _, g := range order {
+		if memberGroupRank(MemberGroups, g) == len(MemberGroups) {
+			return fmt.Errorf("Unknown member group: %s", g)
+		}
+	}
+	return nil
+}
+
+func memberGroupRank(order []string, group string) int {
+	for i, g := range order {
+		if strings.EqualFold(g, group) {
+			return i
+		}
+	}
+	return len(order)
+}
+
+// memberGroup classifies a class body declaration into one of MemberGroups.
+func memberGroup(ctx parser.IClassBodyDeclarationContext) string {
+	if ctx.Block() != nil {
+		return "initializers"
+	}
+	member := ctx.MemberDeclaration()
+	if member == nil {
+		return ""
+	}
+	modifiers := map[string]bool{}
+	for _, m := range ctx.AllModifier() {
+		if m.Annotation() == nil {
+			modifiers[strings.ToLower(m.GetText())] = true
+		}
+	}
+	switch {
+	case member.FieldDeclaration() != nil:
+		switch {
+		case modifiers["static"] && modifiers["final"]:
+			return "constants"
+		case modifiers["static"]:
+			return "static-fields"
+		}
+		return "fields"
+	case member.PropertyDeclaration() != nil:
+		return "properties"
+	case member.ConstructorDeclaration() != nil:
+		return "constructors"
+	case member.MethodDeclaration() != nil:
+		if modifiers["global"] || modifiers["public"] || modifiers["protected"] || modifiers["webservice"] {
+			return "public-methods"
+		}
+		return "private-methods"
+	}
+	return "inner-types"
+}
+
+// initialization returns "static" or "instance" if ctx is an initializer
+// block or a field, which are initialized in declaration order, and whether
+// it is a block or has an initializer.
+func initialization(ctx parser.IClassBodyDeclarationContext) (kind string, initializes bool) {
+	kindOf := func(static bool) string {
+		if static {
+			return "static"
+		}
+		return "instance"
+	}
+	if ctx.Block() != nil {
+		return kindOf(ctx.STATIC() != nil), true
+	}
+	member := ctx.MemberDeclaration()
+	if member == nil || member.FieldDeclaration() == nil {
+		return "", false
+	}
+	for _, d := range member.FieldDeclaration().VariableDeclarators().AllVariableDeclarator() {
+		initializes = initializes || d.Expression() != nil
+	}
+	for _, m := range ctx.AllModifier() {
+		if m.STATIC() != nil {
+			return kindOf(true), initializes
+		}
+	}
+	return kindOf(false), initializes
+}
+
+// sortMembers orders formatted class body declarations by group, keeping
+// source order within each group.  Groups are separated by a blank line, and
+// blank lines between members of the same group are kept.  Comments
+// preceding a member are part of its formatted text, so they move with it.
+//
+// Initializer blocks and field initializers run in declaration order, so
+// initialized static fields keep their relative order, along with all other
+// static fields and static initializer blocks if there are any blocks.  The
+// same applies to instance fields and blocks.  Each such sequence is placed
+// with the earliest group any of its members belongs to.
+func (v *FormatVisitor) sortMembers(decls []parser.IClassBodyDeclarationContext, formatted []string) ([]parser.IClassBodyDeclarationContext, []string) {
+	order := v.options.MemberOrder
+	if len(order) == 0 {
+		order = MemberGroups
+	}
+	type member struct {
+		rank int
+		decl parser.IClassBodyDeclarationContext
+		text string
+	}
+	hasBlock := map[string]bool{}
+	for _, d := range decls {
+		if kind, _ := initialization(d); d.Block() != nil {
+			hasBlock[kind] = true
+		}
+	}
+	// ordered reports whether d keeps its place in its initialization sequence
+	ordered := func(d parser.IClassBodyDeclarationContext) (string, bool) {
+		kind, initializes := initialization(d)
+		return kind, kind != "" && (initializes || hasBlock[kind])
+	}
+	members := make([]member, len(decls))
+	initRank := map[string]int{}
+	for i, d := range decls {
+		members[i] = member{
+			rank: memberGroupRank(order, memberGroup(d)),
+			decl: d,
+			text: formatted[i],
+		}
+		if kind, ok := ordered(d); ok {
+			if r, seen := initRank[kind]; !seen || members[i].rank < r {
+				initRank[kind] = members[i].rank
+			}
+		}
+	}
+	for i := range members {
+		if kind, ok := ordered(members[i].decl); ok {
+			members[i].rank = initRank[kind]
+		}
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].rank < members[j].rank
+	})
+	sortedDecls := make([]parser.IClassBodyDeclarationContext, len(members))
+	sorted := make([]string, len(members))
+	for i, m := range members {
+		sortedDecls[i] = m.decl
+		sorted[i] = m.text
+		switch {
+		case i == 0:
+			sorted[i] = strings.TrimLeft(m.text, "\n")
+		case m.rank != members[i-1].rank:
+			sorted[i] = "\n" + strings.TrimLeft(m.text, "\n")
+		}
+	}
+	return sortedDecls, sorted
+}
+
+// separateMembers puts a blank line before and after each method,
+// constructor, property and inner type.
+func separateMembers(decls []parser.IClassBodyDeclarationContext, formatted []string) []string {
+	separated := func(d parser.IClassBodyDeclarationContext) bool {
+		switch memberGroup(d) {
+		case "public-methods", "private-methods", "constructors", "properties", "inner-types":
+			return true
+		}
+		return false
+	}
+	for i := 1; i < len(decls); i++ {
+		if (separated(decls[i-1]) || separated(decls[i])) && !strings.HasPrefix(formatted[i], "\n") {
+			formatted[i] = "\n" + formatted[i]
+		}
+	}
+	return formatted
+}
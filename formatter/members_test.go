@@ -0,0 +1,178 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestSortMembers(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{SortMembers: true},
+				`public class Foo {
+	public class Inner {}
+	private void helper() {}
+
+	/**
+	 * Does the thing
+	 */
+	public void run() {}
+	public Foo() {}
+	// the count
+	Integer count;
+	private static Integer instances;
+	public static final String NAME = 'foo';
+	public String label { get; set; }
+}`,
+				`public class Foo {
+	public static final String NAME = 'foo';
+
+	private static Integer instances;
+
+	// the count
+	Integer count;
+
+	public String label {get; set;}
+
+	public Foo() {}
+
+	/**
+	 * Does the thing
+	 */
+	public void run() {}
+
+	private void helper() {}
+
+	public class Inner {}
+}`},
+			{
+				Options{SortMembers: true, MemberOrder: []string{"constructors", "fields"}},
+				`public class Foo {
+	void a() {}
+	Integer x;
+	public Foo() {}
+	void b() {}
+}`,
+				`public class Foo {
+	public Foo() {}
+
+	Integer x;
+
+	void a() {}
+	void b() {}
+}`},
+			{
+				Options{SortMembers: true},
+				`public class Foo {
+	void run() {} // runs
+	Integer count; // note
+	public Foo() {} /* builds */ // twice
+	String name;
+}`,
+				`public class Foo {
+	Integer count; // note
+	String name;
+
+	public Foo() {} /* builds */ // twice
+
+	void run() {} // runs
+}`},
+			{
+				Options{SortMembers: true},
+				`public class Foo {
+	static Integer s;
+	static {
+		s = 1;
+	}
+	static final Integer C = s;
+	void run() {}
+	Integer a = 1;
+
+	Integer b = a;
+	{
+		a = 2;
+	}
+	Integer c;
+}`,
+				`public class Foo {
+	static Integer s;
+	static {
+		s = 1;
+	}
+	static final Integer C = s;
+
+	Integer a = 1;
+
+	Integer b = a;
+	{
+		a = 2;
+	}
+	Integer c;
+
+	void run() {}
+}`},
+			{
+				Options{SortMembers: true},
+				`public class Foo {
+	void run() {}
+	String name;
+
+	Integer count;
+	void stop() {}
+
+
+	void start() {}
+}`,
+				`public class Foo {
+	String name;
+
+	Integer count;
+
+	void run() {}
+	void stop() {}
+
+	void start() {}
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
+
+func TestValidateMemberOrder(t *testing.T) {
+	if err := ValidateMemberOrder([]string{"constants", "Fields"}); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := ValidateMemberOrder([]string{"methods"}); err == nil {
+		t.Errorf("expected error for unknown group")
+	}
+}
@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"strings"
+)
+
+// modifierRank orders modifiers when Options.SortModifiers is set: access
+// modifiers, then other keywords, then sharing declarations.
+var modifierRank = map[string]int{
+	"global":            0,
+	"public":            1,
+	"protected":         2,
+	"private":           3,
+	"webservice":        4,
+	"static":            10,
+	"final":             11,
+	"override":          12,
+	"virtual":           13,
+	"abstract":          14,
+	"transient":         15,
+	"testmethod":        16,
+	"with sharing":      20,
+	"without sharing":   21,
+	"inherited sharing": 22,
+}
+
+func rankModifier(modifier string) int {
+	return modifierRank[strings.ToLower(strings.Join(strings.Fields(modifier), " "))]
+}
+
+// knownAnnotations maps lowercased annotation names to their canonical
+// spelling, used when Options.NormalizeAnnotations is set.
+var knownAnnotations = map[string]string{}
+
+func init() {
+	for _, a := range []string{
+		"AuraEnabled",
+		"Deprecated",
+		"Future",
+		"HttpDelete",
+		"HttpGet",
+		"HttpPatch",
+		"HttpPost",
+		"HttpPut",
+		"InvocableMethod",
+		"InvocableVariable",
+		"IsTest",
+		"JsonAccess",
+		"NamespaceAccessible",
+		"ReadOnly",
+		"RemoteAction",
+		"RestResource",
+		"SuppressWarnings",
+		"TestSetup",
+		"TestVisible",
+	} {
+		knownAnnotations[strings.ToLower(a)] = a
+	}
+}
+
+func normalizeAnnotation(name string) string {
+	if canonical, ok := knownAnnotations[strings.ToLower(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+// isInlineAnnotation reports whether an annotation should stay on the same
+// line as the declaration it modifies.  Only annotations without arguments
+// are kept inline.
+func (v *FormatVisitor) isInlineAnnotation(name string, hasArguments bool) bool {
+	if hasArguments {
+		return false
+	}
+	for _, a := range v.options.InlineAnnotations {
+		if strings.EqualFold(strings.TrimPrefix(a, "@"), name) {
+			return true
+		}
+	}
+	return false
+}
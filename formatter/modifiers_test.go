@@ -0,0 +1,85 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestModifiers(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{},
+				`static public class Foo { final static private Integer x = 1; }`,
+				`static public class Foo {
+	final static private Integer x = 1;
+}`},
+			{
+				Options{SortModifiers: true},
+				`with sharing virtual public class Foo { final static private Integer x = 1; override public void bar() {} }`,
+				`public virtual with sharing class Foo {
+	private static final Integer x = 1;
+	public override void bar() {}
+}`},
+			{
+				Options{SortModifiers: true},
+				`public class Foo { static @isTest private void bar() {} }`,
+				`public class Foo {
+	@isTest
+	private static void bar() {}
+}`},
+			{
+				Options{NormalizeAnnotations: true},
+				`@isTest public class Foo { @AURAENABLED(cacheable=true) public static String bar() { return null; } @CustomThing public void baz() {} }`,
+				`@IsTest
+public class Foo {
+	@AuraEnabled(cacheable = true)
+	public static String bar() {
+		return null;
+	}
+	@CustomThing
+	public void baz() {}
+}`},
+			{
+				Options{InlineAnnotations: []string{"TestVisible"}},
+				`public class Foo { @TestVisible private static Integer count; @TestVisible @Deprecated private void bar() {} @TestVisible(foo=true) Integer x; }`,
+				`public class Foo {
+	@TestVisible private static Integer count;
+	@Deprecated
+	@TestVisible private void bar() {}
+	@TestVisible(foo = true)
+	Integer x;
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package formatter
+
+// DefaultMaxWidth is the line length used when Options.MaxWidth is not set.
+const DefaultMaxWidth = 100
+
+// DefaultMaxBlankLines is the number of consecutive blank lines preserved
+// when Options.MaxBlankLines is nil.
+const DefaultMaxBlankLines = 1
+
+// TabWidth is the number of columns an indentation tab counts for when
+// comparing a line to the maximum width.
+const TabWidth = 4
+
+// Options control optional formatting behavior.  The zero value formats
+// code the same way apexfmt always has.
+type Options struct {
+	// Simplify applies behavior-preserving rewrites, like gofmt -s.
+	Simplify bool
+	// SortModifiers orders modifiers canonically: access modifier, then
+	// static, final, override, virtual, abstract, transient, and finally
+	// sharing declarations.  Annotations always come first.
+	SortModifiers bool
+	// NormalizeAnnotations spells known annotations canonically, e.g.
+	// @isTest as @IsTest.
+	NormalizeAnnotations bool
+	// InlineAnnotations lists annotations, such as TestVisible, that stay on
+	// the same line as the declaration when they have no arguments.
+	InlineAnnotations []string
+	// SortTriggerEvents orders trigger events canonically: before events,
+	// then after events, each in insert, update, delete, undelete order.
+	SortTriggerEvents bool
+	// SortMembers orders class members by the groups in MemberOrder,
+	// keeping source order within each group.
+	SortMembers bool
+	// MemberOrder lists member groups from MemberGroups in the order used by
+	// SortMembers.  Members in unlisted groups come last.  Empty means
+	// MemberGroups.
+	MemberOrder []string
+	// MaxBlankLines is the number of consecutive blank lines to preserve.
+	// Zero removes blank lines.  Nil means DefaultMaxBlankLines.
+	MaxBlankLines *int
+	// SeparateMembers requires a blank line before and after methods,
+	// constructors, properties and inner types in a class body.
+	SeparateMembers bool
+	// TrimBlocks removes blank lines at the start and end of blocks.
+	TrimBlocks bool
+	// AllmanBraces puts the opening brace of class, interface, constructor
+	// and method bodies on its own line.
+	AllmanBraces bool
+	// KeepSingleLineGuards leaves single-line if statements whose body is a
+	// return, throw, break or continue without braces, e.g. `if (x) return;`.
+	KeepSingleLineGuards bool
+	// DocComments reformats ApexDoc comments: the ` * ` gutter is
+	// normalized, text is reflowed to DocCommentWidth, @param descriptions
+	// are aligned and tags are ordered.  @example blocks are left verbatim.
+	DocComments bool
+	// DocCommentWidth is the maximum width of ApexDoc comment lines, not
+	// counting indentation.  Zero means DefaultDocCommentWidth.
+	DocCommentWidth int
+	// MaxWidth is the line length, including indentation, beyond which lists
+	// such as trigger events are wrapped one item per line.  Zero means
+	// DefaultMaxWidth.
+	MaxWidth int
+	// AddDocStubs inserts an ApexDoc template before global and public
+	// classes, interfaces, enums and methods that have no ApexDoc comment.
+	AddDocStubs bool
+	// RemoveUnusedLocals deletes local variables that are never read and
+	// whose initializers have no side effects.  Declarations with comments
+	// are kept.
+	RemoveUnusedLocals bool
+	// Compact prints code with minimal whitespace to reduce its size.
+	Compact bool
+	// StripComments removes comments other than ApexDoc comments.
+	StripComments bool
+	// Rewrites are applied in order before formatting, like gofmt -r.
+	Rewrites []*RewriteRule
+}
@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Parse reads Apex source from filename, or from reader if filename is
+// empty, and parses it.  It returns the parse tree and the token stream it
+// was parsed from, which holds comments and whitespace on hidden channels.
+// Syntax errors are returned as *SyntaxErrors.
+func Parse(filename string, reader io.Reader) (parser.ICompilationUnitContext, *antlr.CommonTokenStream, error) {
+	src, err := ReadSource(filename, reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseSource(filename, src)
+}
+
+// ReadSource reads Apex source from filename, or from reader if filename is
+// empty.
+func ReadSource(filename string, reader io.Reader) ([]byte, error) {
+	src, err := readFile(filename, reader)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read file %s: %w", sourceName(filename), err)
+	}
+	return src, nil
+}
+
+// ParseSource parses src like Parse.  filename is only used in errors.
+func ParseSource(filename string, src []byte) (parser.ICompilationUnitContext, *antlr.CommonTokenStream, error) {
+	input := antlr.NewInputStream(string(src))
+	lexer := parser.NewApexLexer(input)
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+	p := parser.NewApexParser(stream)
+	p.RemoveErrorListeners()
+	errors := &errorListener{filename: filename}
+	p.AddErrorListener(errors)
+
+	tree := p.CompilationUnit()
+	if err := errors.err(); err != nil {
+		return nil, nil, err
+	}
+	return tree, stream, nil
+}
+
+func sourceName(filename string) string {
+	if filename != "" {
+		return filename
+	}
+	return "<stdin>"
+}
@@ -0,0 +1,31 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tree, tokens, err := Parse("", strings.NewReader(`public class Foo { /** doc */ void run() {} }`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if tree.TypeDeclaration() == nil || tokens.Size() == 0 {
+		t.Errorf("Expected a class and its tokens")
+	}
+
+	_, _, err = ParseSource("Foo.cls", []byte(`public class Foo {`))
+	var syntaxErrors *SyntaxErrors
+	if !errors.As(err, &syntaxErrors) {
+		t.Fatalf("Expected syntax errors, got %v", err)
+	}
+	if syntaxErrors.Filename != "Foo.cls" || len(syntaxErrors.Errors) == 0 {
+		t.Errorf("unexpected syntax errors: %v", syntaxErrors)
+	}
+
+	_, _, err = Parse("does-not-exist.cls", nil)
+	if err == nil || !strings.HasPrefix(err.Error(), "Failed to read file does-not-exist.cls") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,273 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// RewriteRule rewrites expressions matching a pattern, like gofmt -r.  The
+// rule `System.assertEquals(a, b) -> Assert.areEqual(a, b)` treats the
+// single-letter identifiers a and b as wildcards that match any expression.
+// The replacement may also be a statement, e.g. `Database.insert(x) ->
+// insert x`, in which case only expression statements are rewritten.
+type RewriteRule struct {
+	rule        string
+	pattern     parser.IExpressionContext
+	replacement antlr.ParserRuleContext
+	tokens      *antlr.CommonTokenStream
+	statement   bool
+}
+
+// ParseRewriteRule parses a rule of the form `pattern -> replacement`.
+func ParseRewriteRule(rule string) (*RewriteRule, error) {
+	parts, ok := splitRule(rule)
+	if !ok {
+		return nil, fmt.Errorf("Rewrite rule must be of the form 'pattern -> replacement': %s", rule)
+	}
+	pattern, _, err := parseExpression(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse rewrite pattern %q: %w", parts[0], err)
+	}
+	r := &RewriteRule{rule: rule, pattern: pattern}
+	replacement, tokens, err := parseExpression(strings.TrimSpace(parts[1]))
+	if err == nil {
+		r.replacement, r.tokens = replacement, tokens
+	} else {
+		statement, tokens, err := parseStatement(strings.TrimSuffix(strings.TrimSpace(parts[1]), ";") + ";")
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse rewrite replacement %q: %w", parts[1], err)
+		}
+		r.replacement, r.tokens, r.statement = statement, tokens, true
+	}
+	wildcards := make(map[string]struct{})
+	walkTree(pattern, func(t antlr.Tree) {
+		if name, ok := wildcard(t); ok {
+			wildcards[name] = struct{}{}
+		}
+	})
+	var unbound error
+	walkTree(r.replacement, func(t antlr.Tree) {
+		if name, ok := wildcard(t); ok {
+			if _, bound := wildcards[name]; !bound && unbound == nil {
+				unbound = fmt.Errorf("Wildcard %s in replacement does not appear in pattern: %s", name, rule)
+			}
+		}
+	})
+	if unbound != nil {
+		return nil, unbound
+	}
+	return r, nil
+}
+
+// splitRule splits rule at the first `->` outside string literals and
+// comments.
+func splitRule(rule string) ([]string, bool) {
+	lexer := parser.NewApexLexer(antlr.NewInputStream(rule))
+	lexer.RemoveErrorListeners()
+	var prev antlr.Token
+	for t := lexer.NextToken(); t.GetTokenType() != antlr.TokenEOF; t = lexer.NextToken() {
+		// the lexer has no -> token, so look for adjacent - and >
+		if prev != nil && prev.GetTokenType() == parser.ApexLexerSUB && t.GetTokenType() == parser.ApexLexerGT && t.GetStart() == prev.GetStop()+1 {
+			runes := []rune(rule)
+			return []string{string(runes[:prev.GetStart()]), string(runes[t.GetStop()+1:])}, true
+		}
+		prev = t
+	}
+	return nil, false
+}
+
+func (r *RewriteRule) String() string {
+	return r.rule
+}
+
+// Apply rewrites every match of the rule in src.  The result is unformatted.
+func (r *RewriteRule) Apply(filename string, src []byte) ([]byte, error) {
+	tree, stream, err := ParseSource(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	var out strings.Builder
+	out.WriteString(stream.GetTextFromInterval(antlr.NewInterval(0, tree.GetStart().GetTokenIndex()-1)))
+	out.WriteString(r.rewrite(stream, tree))
+	out.WriteString(stream.GetTextFromInterval(antlr.NewInterval(tree.GetStop().GetTokenIndex()+1, stream.Size()-1)))
+	return []byte(out.String()), nil
+}
+
+// rewrite returns the source text of ctx with all matches replaced.
+func (r *RewriteRule) rewrite(tokens *antlr.CommonTokenStream, ctx antlr.ParserRuleContext) string {
+	if bindings, ok := r.matchContext(ctx); ok {
+		rewritten := make(map[string]string)
+		for name, b := range bindings {
+			rewritten[name] = r.rewrite(tokens, b)
+		}
+		return r.substitute(rewritten, bindings)
+	}
+	var out strings.Builder
+	pos := ctx.GetStart().GetTokenIndex()
+	for _, child := range ctx.GetChildren() {
+		c, ok := child.(antlr.ParserRuleContext)
+		if !ok || c.GetStop() == nil || c.GetStop().GetTokenIndex() < c.GetStart().GetTokenIndex() {
+			continue
+		}
+		out.WriteString(tokens.GetTextFromInterval(antlr.NewInterval(pos, c.GetStart().GetTokenIndex()-1)))
+		out.WriteString(r.rewrite(tokens, c))
+		pos = c.GetStop().GetTokenIndex() + 1
+	}
+	out.WriteString(tokens.GetTextFromInterval(antlr.NewInterval(pos, ctx.GetStop().GetTokenIndex())))
+	return out.String()
+}
+
+// matchContext matches the pattern against ctx.  Statement replacements
+// replace the whole expression statement.
+func (r *RewriteRule) matchContext(ctx antlr.ParserRuleContext) (map[string]antlr.ParserRuleContext, bool) {
+	bindings := make(map[string]antlr.ParserRuleContext)
+	if r.statement {
+		s, ok := ctx.(*parser.ExpressionStatementContext)
+		if !ok {
+			return nil, false
+		}
+		return bindings, match(r.pattern, s.Expression(), bindings)
+	}
+	if _, ok := ctx.(parser.IExpressionContext); !ok {
+		return nil, false
+	}
+	return bindings, match(r.pattern, ctx, bindings)
+}
+
+// substitute returns the replacement text with wildcards replaced by the
+// rewritten text of their bindings.
+func (r *RewriteRule) substitute(rewritten map[string]string, bindings map[string]antlr.ParserRuleContext) string {
+	var out strings.Builder
+	pos := r.replacement.GetStart().GetTokenIndex()
+	walkTree(r.replacement, func(t antlr.Tree) {
+		name, ok := wildcard(t)
+		if !ok {
+			return
+		}
+		w := t.(antlr.ParserRuleContext)
+		out.WriteString(r.tokens.GetTextFromInterval(antlr.NewInterval(pos, w.GetStart().GetTokenIndex()-1)))
+		if isAtomic(bindings[name]) || isWholeExpression(w) {
+			out.WriteString(rewritten[name])
+		} else {
+			out.WriteString("(" + rewritten[name] + ")")
+		}
+		pos = w.GetStop().GetTokenIndex() + 1
+	})
+	out.WriteString(r.tokens.GetTextFromInterval(antlr.NewInterval(pos, r.replacement.GetStop().GetTokenIndex())))
+	return out.String()
+}
+
+// match reports whether node has the same structure as pattern, binding
+// wildcards in pattern to expressions in node.  Identifiers and keywords are
+// compared case-insensitively.
+func match(pattern antlr.Tree, node antlr.Tree, bindings map[string]antlr.ParserRuleContext) bool {
+	if name, ok := wildcard(pattern); ok {
+		e, isExpression := node.(parser.IExpressionContext)
+		if !isExpression {
+			return false
+		}
+		if bound, seen := bindings[name]; seen {
+			return strings.EqualFold(bound.GetText(), e.GetText())
+		}
+		bindings[name] = e
+		return true
+	}
+	if p, ok := pattern.(antlr.TerminalNode); ok {
+		n, ok := node.(antlr.TerminalNode)
+		if !ok || p.GetSymbol().GetTokenType() != n.GetSymbol().GetTokenType() {
+			return false
+		}
+		if p.GetSymbol().GetTokenType() == parser.ApexLexerStringLiteral {
+			return p.GetText() == n.GetText()
+		}
+		return strings.EqualFold(p.GetText(), n.GetText())
+	}
+	if fmt.Sprintf("%T", pattern) != fmt.Sprintf("%T", node) {
+		return false
+	}
+	pc, nc := pattern.GetChildren(), node.GetChildren()
+	if len(pc) != len(nc) {
+		return false
+	}
+	for i := range pc {
+		if !match(pc[i], nc[i], bindings) {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcard returns the name of a single-letter identifier expression.
+func wildcard(t antlr.Tree) (string, bool) {
+	p, ok := t.(*parser.PrimaryExpressionContext)
+	if !ok {
+		return "", false
+	}
+	id, ok := p.Primary().(*parser.IdPrimaryContext)
+	if !ok {
+		return "", false
+	}
+	name := id.GetText()
+	if len(name) != 1 || !unicode.IsLetter(rune(name[0])) {
+		return "", false
+	}
+	return strings.ToLower(name), true
+}
+
+// walkTree calls fn for each node in t, in source order, without descending
+// into wildcards.
+func walkTree(t antlr.Tree, fn func(antlr.Tree)) {
+	fn(t)
+	if _, ok := wildcard(t); ok {
+		return
+	}
+	for _, c := range t.GetChildren() {
+		walkTree(c, fn)
+	}
+}
+
+// parseExpression parses src as an expression statement.
+func parseExpression(src string) (parser.IExpressionContext, *antlr.CommonTokenStream, error) {
+	s, stream, err := parseStatement(src + ";")
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.ExpressionStatement() == nil {
+		return nil, nil, fmt.Errorf("not an expression")
+	}
+	return s.ExpressionStatement().Expression(), stream, nil
+}
+
+// parseStatement parses src as the only statement in a block, since
+// parsing a statement or expression on its own stops calls such as `foo(a)`
+// after the method name.
+func parseStatement(src string) (parser.IStatementContext, *antlr.CommonTokenStream, error) {
+	p, stream, errors := newParser("{" + src + "}")
+	b := p.Block()
+	if err := errors.err(); err != nil {
+		return nil, nil, err
+	}
+	if stream.LA(1) != antlr.TokenEOF {
+		return nil, nil, fmt.Errorf("unexpected %s", stream.LT(1).GetText())
+	}
+	if len(b.AllStatement()) != 1 {
+		return nil, nil, fmt.Errorf("expected a single statement")
+	}
+	return b.Statement(0), stream, nil
+}
+
+func newParser(src string) (*parser.ApexParser, *antlr.CommonTokenStream, *errorListener) {
+	input := antlr.NewInputStream(src)
+	lexer := parser.NewApexLexer(input)
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+	p := parser.NewApexParser(stream)
+	p.RemoveErrorListeners()
+	errors := &errorListener{}
+	p.AddErrorListener(errors)
+	return p, stream, errors
+}
@@ -0,0 +1,120 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestRewrite(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			rule   string
+			input  string
+			output string
+		}{
+			{
+				`System.assertEquals(a, b, c) -> Assert.areEqual(a, b, c)`,
+				`class T {
+	void t() {
+		System.assertEquals(1, x.size(), 'one');
+		system.ASSERTEQUALS(y, z);
+	}
+}`,
+				`class T {
+	void t() {
+		Assert.areEqual(1, x.size(), 'one');
+		system.ASSERTEQUALS(y, z);
+	}
+}
+`},
+			{
+				`log(a) -> Logger.info('x -> ' + a)`,
+				`class T {
+	void t() {
+		log('->' + y);
+	}
+}`,
+				`class T {
+	void t() {
+		Logger.info('x -> ' + ('->' + y));
+	}
+}
+`},
+			{
+				`Database.insert(x) -> insert x`,
+				`class T {
+	void t() {
+		Database.insert(new List<Account>{ a });
+		Boolean ok = Database.insert(a).isSuccess();
+	}
+}`,
+				`class T {
+	void t() {
+		insert new List<Account>{ a };
+		Boolean ok = Database.insert(a).isSuccess();
+	}
+}
+`},
+			{
+				`a.size() == 0 -> a.isEmpty()`,
+				`class T {
+	Boolean t() {
+		return z.get(w.size() == 0).size() == 0;
+	}
+}`,
+				`class T {
+	Boolean t() {
+		return z.get(w.isEmpty()).isEmpty();
+	}
+}
+`},
+			{
+				`a == a -> true`,
+				`class T {
+	Boolean t() {
+		return x.Id == X.id && x == y;
+	}
+}`,
+				`class T {
+	Boolean t() {
+		return true && x == y;
+	}
+}
+`},
+		}
+	for _, tt := range tests {
+		r, err := ParseRewriteRule(tt.rule)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing rule: %s", err.Error())
+		}
+		f := NewFormatter("", strings.NewReader(tt.input))
+		f.SetOptions(Options{Rewrites: []*RewriteRule{r}})
+		out, err := f.Formatted()
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
+
+func TestParseRewriteRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		`System.debug(a)`,
+		`System.debug(a) -> Logger.log(b)`,
+		`System.debug(a -> Logger.log(a)`,
+		`System.debug('a -> b')`,
+		`a -> b -> c`,
+	} {
+		if _, err := ParseRewriteRule(rule); err == nil {
+			t.Errorf("expected error parsing rule %q", rule)
+		}
+	}
+}
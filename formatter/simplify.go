@@ -0,0 +1,263 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Rewrites applied when Options.Simplify is set.  Each must preserve
+// behavior, including for null Booleans: `if (x)` throws when x is null, so
+// `x == true` is only simplified when x cannot be null.
+
+// isAtomic reports whether expr never needs parentheses as an operand.
+func isAtomic(expr antlr.Tree) bool {
+	switch expr.(type) {
+	case *parser.PrimaryExpressionContext,
+		*parser.DotExpressionContext,
+		*parser.MethodCallExpressionContext,
+		*parser.ArrayExpressionContext,
+		*parser.SubExpressionContext:
+		return true
+	}
+	return false
+}
+
+// isWholeExpression reports whether expr is the entire expression in its
+// context, such as a condition, statement, argument, or initializer.
+func isWholeExpression(expr antlr.Tree) bool {
+	switch p := expr.GetParent().(type) {
+	case *parser.ParExpressionContext,
+		*parser.ExpressionStatementContext,
+		*parser.ReturnStatementContext,
+		*parser.ThrowStatementContext,
+		*parser.VariableDeclaratorContext,
+		*parser.InsertStatementContext,
+		*parser.UpdateStatementContext,
+		*parser.UpsertStatementContext,
+		*parser.DeleteStatementContext,
+		*parser.UndeleteStatementContext:
+		return true
+	case *parser.ExpressionListContext:
+		_, assign := expr.(*parser.AssignExpressionContext)
+		return !assign
+	case *parser.AssignExpressionContext:
+		return p.Expression(1) == expr
+	}
+	return false
+}
+
+func redundantParens(ctx *parser.SubExpressionContext) bool {
+	if _, cast := ctx.GetParent().(*parser.CastExpressionContext); cast {
+		return false
+	}
+	if _, assign := ctx.Expression().(*parser.AssignExpressionContext); assign {
+		return false
+	}
+	return isWholeExpression(ctx) || isAtomic(ctx.Expression())
+}
+
+// isNonNullBoolean reports whether expr always evaluates to true or false.
+func isNonNullBoolean(expr antlr.Tree) bool {
+	switch e := expr.(type) {
+	case *parser.CmpExpressionContext,
+		*parser.EqualityExpressionContext,
+		*parser.InstanceOfExpressionContext,
+		*parser.LogAndExpressionContext,
+		*parser.LogOrExpressionContext:
+		return true
+	case *parser.NegExpressionContext:
+		return e.BANG() != nil
+	case *parser.SubExpressionContext:
+		return isNonNullBoolean(e.Expression())
+	case *parser.PrimaryExpressionContext:
+		_, ok := booleanLiteral(e)
+		return ok
+	}
+	return false
+}
+
+func booleanLiteral(expr antlr.Tree) (value bool, ok bool) {
+	p, isPrimary := expr.(*parser.PrimaryExpressionContext)
+	if !isPrimary {
+		return false, false
+	}
+	l, isLiteral := p.Primary().(*parser.LiteralPrimaryContext)
+	if !isLiteral || l.Literal().BooleanLiteral() == nil {
+		return false, false
+	}
+	return strings.EqualFold(l.Literal().GetText(), "true"), true
+}
+
+// simplifyBooleanComparison rewrites `x == true` as `x` and `x == false` as
+// `!x`.
+func (v *FormatVisitor) simplifyBooleanComparison(ctx *parser.EqualityExpressionContext) (string, bool) {
+	var negate bool
+	switch {
+	case ctx.EQUAL() != nil:
+		negate = false
+	case ctx.NOTEQUAL() != nil, ctx.LESSANDGREATER() != nil:
+		negate = true
+	default:
+		return "", false
+	}
+	operand := ctx.Expression(0)
+	literal, ok := booleanLiteral(ctx.Expression(1))
+	if !ok {
+		operand = ctx.Expression(1)
+		literal, ok = booleanLiteral(ctx.Expression(0))
+	}
+	if !ok || !isNonNullBoolean(operand) {
+		return "", false
+	}
+	if !literal {
+		negate = !negate
+	}
+	// the operand's parentheses are redundant if the comparison is the
+	// whole expression or is itself parenthesized
+	_, parenthesized := ctx.GetParent().(*parser.SubExpressionContext)
+	if sub, isSub := operand.(*parser.SubExpressionContext); isSub && !negate && (isWholeExpression(ctx) || parenthesized) {
+		operand = sub.Expression()
+	}
+	out := v.visitRule(operand).(string)
+	if negate {
+		if !isAtomic(operand) {
+			out = "(" + out + ")"
+		}
+		out = "!" + out
+	}
+	return out, true
+}
+
+// returnsBoolean returns the Boolean literal returned by a statement
+// consisting only of `return true;` or `return false;`, optionally in a
+// block.
+func returnsBoolean(stmt parser.IStatementContext) (value bool, ok bool) {
+	if block := stmt.Block(); block != nil {
+		if len(block.AllStatement()) != 1 {
+			return false, false
+		}
+		stmt = block.Statement(0)
+	}
+	r := stmt.ReturnStatement()
+	if r == nil || r.Expression() == nil {
+		return false, false
+	}
+	return booleanLiteral(r.Expression())
+}
+
+// simplifyIfReturn rewrites `if (c) { return true; } else { return false; }`
+// as `return c;`.
+func (v *FormatVisitor) simplifyIfReturn(ctx *parser.IfStatementContext) (string, bool) {
+	if ctx.ELSE() == nil || v.hasComments(ctx) {
+		return "", false
+	}
+	then, ok := returnsBoolean(ctx.Statement(0))
+	if !ok {
+		return "", false
+	}
+	otherwise, ok := returnsBoolean(ctx.Statement(1))
+	if !ok || then == otherwise {
+		return "", false
+	}
+	cond := ctx.ParExpression().Expression()
+	if then {
+		// `if (c)` throws if c is null, but `return c;` would not
+		if !isNonNullBoolean(cond) {
+			return "", false
+		}
+		if sub, isSub := cond.(*parser.SubExpressionContext); isSub {
+			return fmt.Sprintf("return %s;", v.visitRule(sub.Expression())), true
+		}
+		return fmt.Sprintf("return %s;", v.visitRule(cond)), true
+	}
+	if isAtomic(cond) {
+		return fmt.Sprintf("return !%s;", v.visitRule(cond)), true
+	}
+	return fmt.Sprintf("return !(%s);", v.visitRule(cond)), true
+}
+
+// simplifyElseIf applies simplifyIfReturn to an if statement in an else
+// branch, whose result must then be wrapped in a block.
+func (v *FormatVisitor) simplifyElseIf(ctx *parser.IfStatementContext) (string, bool) {
+	if !v.options.Simplify || v.tokens.GetHiddenTokensToLeft(ctx.GetStart().GetTokenIndex(), COMMENTS_CHANNEL) != nil {
+		return "", false
+	}
+	return v.simplifyIfReturn(ctx)
+}
+
+func isEmptyBlock(stmt parser.IStatementContext) bool {
+	block := stmt.Block()
+	return block != nil && len(block.AllStatement()) == 0
+}
+
+// hasComments reports whether any comments appear within ctx.
+func (v *FormatVisitor) hasComments(ctx antlr.ParserRuleContext) bool {
+	for i := ctx.GetStart().GetTokenIndex(); i <= ctx.GetStop().GetTokenIndex(); i++ {
+		if v.tokens.Get(i).GetChannel() == COMMENTS_CHANNEL {
+			return true
+		}
+	}
+	return false
+}
+
+// redundantThis reports whether `this.` can be dropped from ctx without a
+// local variable or parameter capturing the name.
+func redundantThis(ctx *parser.DotExpressionContext) bool {
+	p, ok := ctx.Expression().(*parser.PrimaryExpressionContext)
+	if !ok {
+		return false
+	}
+	if _, isThis := p.Primary().(*parser.ThisPrimaryContext); !isThis {
+		return false
+	}
+	if ctx.DotMethodCall() != nil {
+		return true
+	}
+	name := strings.ToLower(ctx.AnyId().GetText())
+	_, shadowed := localNames(ctx)[name]
+	return !shadowed
+}
+
+// localNames returns the lowercased names of all parameters and local
+// variables declared in the member enclosing node.  Property setters
+// implicitly declare `value`.
+func localNames(node antlr.Tree) map[string]struct{} {
+	names := map[string]struct{}{"value": {}}
+	var member antlr.Tree
+	for parent := node.GetParent(); parent != nil; parent = parent.GetParent() {
+		switch parent.(type) {
+		case *parser.MethodDeclarationContext,
+			*parser.ConstructorDeclarationContext,
+			*parser.PropertyDeclarationContext,
+			*parser.ClassBodyDeclarationContext:
+			member = parent
+		}
+		if member != nil {
+			break
+		}
+	}
+	if member == nil {
+		return names
+	}
+	var collect func(antlr.Tree)
+	collect = func(t antlr.Tree) {
+		switch n := t.(type) {
+		case *parser.FormalParameterContext:
+			names[strings.ToLower(n.Id().GetText())] = struct{}{}
+		case *parser.VariableDeclaratorContext:
+			names[strings.ToLower(n.Id().GetText())] = struct{}{}
+		case *parser.EnhancedForControlContext:
+			names[strings.ToLower(n.Id().GetText())] = struct{}{}
+		case *parser.CatchClauseContext:
+			names[strings.ToLower(n.Id().GetText())] = struct{}{}
+		}
+		for _, c := range t.GetChildren() {
+			collect(c)
+		}
+	}
+	collect(member)
+	return names
+}
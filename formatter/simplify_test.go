@@ -0,0 +1,175 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestSimplify(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			input  string
+			output string
+		}{
+			{
+				`if ((a > b)) { x = (y); }`,
+				`if (a > b) {
+	x = y;
+}`},
+			{
+				`x = (c.d) * (a + b) + bar.foo((e));`,
+				`x = c.d * (a + b) + bar.foo(e);`},
+			{
+				`x = (Integer)(y + 1);`,
+				`x = (Integer)(y + 1);`},
+			{
+				`if ((a > b) == true) { x = 1; }`,
+				`if (a > b) {
+	x = 1;
+}`},
+			{
+				`if (a > b == false) { x = 1; }`,
+				`if (!(a > b)) {
+	x = 1;
+}`},
+			{
+				`if (true != x instanceof Foo) { x = 1; }`,
+				`if (!(x instanceof Foo)) {
+	x = 1;
+}`},
+			{
+				// flag may be null, so comparing it to true is not redundant
+				`if (flag == true) { x = 1; }`,
+				`if (flag == true) {
+	x = 1;
+}`},
+			{
+				`if (a == b) { return true; } else { return false; }`,
+				`return a == b;`},
+			{
+				`if (isValid()) return false; else return true;`,
+				`return !isValid();`},
+			{
+				`if (isValid()) { return true; } else { return false; }`,
+				`if (isValid()) {
+	return true;
+} else {
+	return false;
+}`},
+			{
+				`if (a) { x = 1; } else if (b == c) { return true; } else { return false; }`,
+				`if (a) {
+	x = 1;
+} else {
+	return b == c;
+}`},
+			{
+				`x = ((a > 1) == true) ? 1 : 2;`,
+				`x = (a > 1) ? 1 : 2;`},
+			{
+				`if (a) { x = 1; } else {}`,
+				`if (a) {
+	x = 1;
+}`},
+			{
+				`if (a) { x = 1; } else { /* nothing to do */ }`,
+				`if (a) {
+	x = 1;
+} else {}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = Options{Simplify: true}
+		out, ok := v.visitRule(p.Statement()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
+
+func TestSimplifyThis(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			input  string
+			output string
+		}{
+			{
+				`public class Foo {
+	Integer count;
+	String name;
+	public Foo(String name) {
+		this.name = name;
+		this.count = 0;
+		this.reset();
+	}
+}`,
+				`public class Foo {
+	Integer count;
+	String name;
+	public Foo(String name) {
+		this.name = name;
+		count = 0;
+		reset();
+	}
+}`},
+			{
+				`public class Foo {
+	Integer total;
+	public void add(List<Integer> values) {
+		for (Integer total : values) {
+			this.total += total;
+		}
+	}
+}`,
+				`public class Foo {
+	Integer total;
+	public void add(List<Integer> values) {
+		for (Integer total : values) {
+			this.total += total;
+		}
+	}
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = Options{Simplify: true}
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
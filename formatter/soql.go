@@ -4,19 +4,40 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
+	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
 	"github.com/octoberswimmer/apexfmt/parser"
 )
 
 type SOQLFormatter struct {
+	filename  string
+	reader    io.Reader
+	options   Options
 	source    []byte
 	formatted []byte
 }
 
-func NewSOQLFormatter() *SOQLFormatter {
-	return &SOQLFormatter{}
+func NewSOQLFormatter(filename string, reader io.Reader) *SOQLFormatter {
+	if filename != "" {
+		return &SOQLFormatter{
+			filename: filename,
+		}
+	}
+	return &SOQLFormatter{
+		reader: reader,
+	}
+}
+
+func (f *SOQLFormatter) SourceName() string {
+	if f.filename != "" {
+		return f.filename
+	}
+	return "<stdin>"
+}
+
+func (f *SOQLFormatter) SetOptions(options Options) {
+	f.options = options
 }
 
 func (f *SOQLFormatter) Formatted() (string, error) {
@@ -41,25 +62,135 @@ func (f *SOQLFormatter) Changed() (bool, error) {
 
 func (f *SOQLFormatter) Format() error {
 	if f.source == nil {
-		src, err := io.ReadAll(os.Stdin)
+		src, err := readFile(f.filename, f.reader)
 		if err != nil {
 			return fmt.Errorf("Failed to read in query: %w", err)
 		}
 		f.source = src
 	}
+	if comments, start, ok := soslStart(f.source); ok {
+		return f.formatSOSL(comments, start)
+	}
 	input := antlr.NewInputStream(string(f.source))
 	lexer := parser.NewApexLexer(input)
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 
 	p := parser.NewApexParser(stream)
 	p.RemoveErrorListeners()
-	p.AddErrorListener(&errorListener{})
+	errors := &errorListener{filename: f.filename}
+	p.AddErrorListener(errors)
 
+	tree := p.Query()
+	errors.expectEOF(stream)
+	if err := errors.err(); err != nil {
+		return err
+	}
 	v := NewFormatVisitor(stream)
-	out, ok := v.visitRule(p.Query()).(string)
+	v.options = f.options
+	out, ok := v.visitRule(tree).(string)
 	if !ok {
 		return fmt.Errorf("Unexpected result parsing apex")
 	}
 	f.formatted = append([]byte(out), '\n')
 	return nil
 }
+
+// SOSL searches are only recognized by the lexer inside brackets, so
+// standalone searches are wrapped before parsing.  Comments before the
+// search, which starts at rune start, are printed one per line and blanked
+// out so that errors are reported at their positions in the source.
+func (f *SOQLFormatter) formatSOSL(comments []string, start int) error {
+	src := []rune(string(f.source))
+	for i, r := range src[:start] {
+		if r != '\n' {
+			src[i] = ' '
+		}
+	}
+	input := antlr.NewInputStream("[" + string(src) + "]")
+	lexer := parser.NewApexLexer(input)
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+	p := parser.NewApexParser(stream)
+	p.RemoveErrorListeners()
+	errors := &errorListener{filename: f.filename}
+	p.AddErrorListener(errors)
+
+	var find string
+	var clauses parser.ISoslClausesContext
+	if stream.LA(1) == parser.ApexLexerFindLiteralAlt {
+		search := p.SoslLiteralAlt()
+		find = search.FindLiteralAlt().GetText()
+		clauses = search.SoslClauses()
+	} else {
+		search := p.SoslLiteral()
+		if search.FindLiteral() == nil {
+			return fmt.Errorf("Unexpected bound expression in SOSL search")
+		}
+		find = search.FindLiteral().GetText()
+		clauses = search.SoslClauses()
+	}
+	errors.expectEOF(stream)
+	for i, e := range errors.errors {
+		if e.Line == 1 {
+			// the opening bracket
+			errors.errors[i].Column--
+		}
+	}
+	if err := errors.err(); err != nil {
+		return err
+	}
+	v := NewFormatVisitor(stream)
+	v.options = f.options
+	out, ok := v.visitRule(clauses).(string)
+	if !ok {
+		return fmt.Errorf("Unexpected result parsing sosl")
+	}
+	var b strings.Builder
+	if !f.options.StripComments {
+		for _, c := range comments {
+			b.WriteString(c + "\n")
+		}
+	}
+	fmt.Fprintf(&b, "FIND %s%s\n", findTerm(find), out)
+	f.formatted = []byte(b.String())
+	return nil
+}
+
+// Source returns the source read by Format.
+func (f *SOQLFormatter) Source() []byte {
+	return f.source
+}
+
+func (f *SOQLFormatter) Write() error {
+	if f.formatted == nil {
+		return fmt.Errorf("No formatted source found")
+	}
+	return writeFile(f.filename, f.formatted)
+}
+
+// soslStart reports whether src is a SOSL search, i.e. its first token is
+// FIND, and if so returns the comments before the search and the index of
+// the rune at which it starts.
+func soslStart(src []byte) ([]string, int, bool) {
+	lexer := parser.NewApexLexer(antlr.NewInputStream(string(src)))
+	lexer.RemoveErrorListeners()
+	var comments []string
+	for t := lexer.NextToken(); t.GetTokenType() != antlr.TokenEOF; t = lexer.NextToken() {
+		switch {
+		case t.GetChannel() == COMMENTS_CHANNEL:
+			comments = append(comments, t.GetText())
+		case t.GetChannel() == antlr.TokenDefaultChannel:
+			if t.GetTokenType() != parser.ApexLexerFIND {
+				return nil, 0, false
+			}
+			return comments, t.GetStart(), true
+		}
+	}
+	return nil, 0, false
+}
+
+// findTerm extracts the search term from a [FIND 'term' or [FIND {term} token
+func findTerm(literal string) string {
+	literal = strings.TrimSpace(strings.TrimPrefix(literal, "["))
+	return strings.TrimSpace(literal[len("FIND"):])
+}
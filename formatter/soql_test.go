@@ -0,0 +1,95 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestSOQLFormatter(t *testing.T) {
+	if testing.Verbose() {
+		log.SetLevel(log.DebugLevel)
+
+	}
+	tests :=
+		[]struct {
+			input  string
+			output string
+		}{
+			{
+				`SELECT Id FROM Account`,
+				`SELECT Id FROM Account
+`},
+			{
+				`SELECT Id, Name FROM Account WHERE Name LIKE 'A%' AND Type = 'Customer' ORDER BY Name`,
+				`SELECT
+	Id,
+	Name
+FROM
+	Account
+WHERE
+	Name LIKE 'A%' AND
+	Type = 'Customer'
+ORDER BY
+	Name
+`},
+			{
+				`FIND {Acme*} IN ALL FIELDS RETURNING Account`,
+				`FIND {Acme*}
+IN ALL FIELDS
+RETURNING Account
+`},
+			{
+				`// accounts named Acme
+/* and their contacts */
+FIND {Acme*} RETURNING Contact`,
+				`// accounts named Acme
+/* and their contacts */
+FIND {Acme*}
+RETURNING Contact
+`},
+			{
+				`find 'Acme*' RETURNING Contact LIMIT 10`,
+				`FIND 'Acme*'
+RETURNING Contact
+LIMIT 10
+`},
+		}
+	for _, tt := range tests {
+		f := NewSOQLFormatter("", strings.NewReader(tt.input))
+		out, err := f.Formatted()
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
+
+func TestSOQLFormatterTrailingInput(t *testing.T) {
+	tests :=
+		[]struct {
+			input string
+			err   string
+		}{
+			{
+				`SELECT Id FROM Account garbage here`,
+				`line 1:31 extraneous input 'here' expecting <EOF>`},
+			{
+				`SELECT Id FROM Account;`,
+				`line 1:22 extraneous input ';' expecting <EOF>`},
+			{
+				`// search
+FIND {Acme*} RETURNING Account garbage`,
+				`line 2:31 extraneous input 'garbage' expecting ']'`},
+		}
+	for _, tt := range tests {
+		f := NewSOQLFormatter("", strings.NewReader(tt.input))
+		_, err := f.Formatted()
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("unexpected error for %q.  expected %q, got %v", tt.input, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+func TestTrigger(t *testing.T) {
+	tests :=
+		[]struct {
+			options Options
+			input   string
+			output  string
+		}{
+			{
+				Options{},
+				`trigger AccountTrigger on Account (after update, before insert) { AccountHandler.run(); }`,
+				`trigger AccountTrigger on Account (after update, before insert) {
+	AccountHandler.run();
+}`},
+			{
+				Options{SortTriggerEvents: true},
+				`trigger AccountTrigger on Account (after undelete, before delete, after insert, before insert) { AccountHandler.run(); }`,
+				`trigger AccountTrigger on Account (before insert, before delete, after insert, after undelete) {
+	AccountHandler.run();
+}`},
+			{
+				Options{SortTriggerEvents: true},
+				`trigger OpportunityLineItemTrigger on OpportunityLineItem (after delete, before update, after insert, before insert, after update, after undelete) { new OpportunityLineItemHandler().run(); }`,
+				`trigger OpportunityLineItemTrigger on OpportunityLineItem (
+	before insert,
+	before update,
+	after insert,
+	after update,
+	after delete,
+	after undelete
+) {
+	new OpportunityLineItemHandler().run();
+}`},
+			{
+				Options{MaxWidth: 40},
+				`trigger AccountTrigger on Account (before insert, after insert) { AccountHandler.run(); }`,
+				`trigger AccountTrigger on Account (
+	before insert,
+	after insert
+) {
+	AccountHandler.run();
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = tt.options
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
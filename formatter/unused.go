@@ -0,0 +1,382 @@
+package formatter
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Kinds of unused declarations.
+const (
+	UnusedMethod    = "private method"
+	UnusedField     = "private field"
+	UnusedProperty  = "private property"
+	UnusedLocal     = "local variable"
+	UnusedParameter = "parameter"
+)
+
+// Unused is a declaration that is never referenced.
+type Unused struct {
+	Kind string
+	Name string
+	// Node is the MethodDeclarationContext, PropertyDeclarationContext,
+	// VariableDeclaratorContext or FormalParameterContext declaring Name.
+	Node antlr.ParserRuleContext
+	// Removable reports whether an unused local variable can be deleted
+	// because its initializer, if any, has no side effects.
+	Removable bool
+}
+
+// FindUnused reports private methods, fields and properties that are never
+// referenced, local variables that are never read, and parameters of
+// private methods that are never read.  References are matched by name,
+// ignoring case, so a use of any variable or member with the same name
+// counts as a reference, as does a `:name` bind in any string literal, which
+// may be a dynamic query.  Members with annotations, such as @TestVisible,
+// and virtual, override, abstract and testMethod methods are not reported.
+//
+// Local variables that are read only by the initializers of removable
+// unused variables are themselves reported as unused.
+func FindUnused(tree antlr.ParseTree) []Unused {
+	return findUnused(tree, nil)
+}
+
+// findUnused is FindUnused, except that local variables for which keep
+// returns true are never removable.
+func findUnused(tree antlr.ParseTree, keep func(*parser.VariableDeclaratorContext) bool) []Unused {
+	var declarations []antlr.ParserRuleContext
+	uses := make(map[string][]antlr.ParserRuleContext)
+	walk(tree, func(ctx antlr.ParserRuleContext) {
+		switch c := ctx.(type) {
+		case *parser.ClassBodyDeclarationContext,
+			*parser.LocalVariableDeclarationContext:
+			declarations = append(declarations, c)
+		}
+		if name := usedName(ctx); name != "" {
+			uses[name] = append(uses[name], ctx)
+		}
+		for _, name := range bindNames(ctx) {
+			uses[name] = append(uses[name], ctx)
+		}
+	})
+
+	var unused []Unused
+	var locals []*parser.VariableDeclaratorContext
+	for _, d := range declarations {
+		switch d := d.(type) {
+		case *parser.ClassBodyDeclarationContext:
+			unused = append(unused, unusedMembers(d, uses)...)
+		case *parser.LocalVariableDeclarationContext:
+			if _, ok := d.GetParent().(*parser.ForInitContext); ok {
+				continue
+			}
+			for _, v := range d.VariableDeclarators().AllVariableDeclarator() {
+				locals = append(locals, v.(*parser.VariableDeclaratorContext))
+			}
+		}
+	}
+
+	// Remove removable variables until no more can be removed, so that
+	// reads in the initializers of removed variables don't count.
+	removed := make(map[antlr.ParserRuleContext]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, v := range locals {
+			// variables that are assigned can't be removed either
+			if removed[v] || references(v.Id().GetText(), localScope(v), uses, removed, true) > 0 {
+				continue
+			}
+			if keep != nil && keep(v) {
+				continue
+			}
+			if v.Expression() == nil || sideEffectFree(v.Expression()) {
+				removed[v] = true
+				changed = true
+			}
+		}
+	}
+	for _, v := range locals {
+		if removed[v] || references(v.Id().GetText(), localScope(v), uses, removed, false) == 0 {
+			unused = append(unused, Unused{Kind: UnusedLocal, Name: v.Id().GetText(), Node: v, Removable: removed[v]})
+		}
+	}
+	sort.SliceStable(unused, func(i, j int) bool {
+		return unused[i].Node.GetStart().GetTokenIndex() < unused[j].Node.GetStart().GetTokenIndex()
+	})
+	return unused
+}
+
+func unusedMembers(d *parser.ClassBodyDeclarationContext, uses map[string][]antlr.ParserRuleContext) []Unused {
+	member := d.MemberDeclaration()
+	if member == nil || !privateMember(d.AllModifier()) {
+		return nil
+	}
+	referenced := func(name string, decl antlr.Tree) bool {
+		for _, u := range uses[strings.ToLower(name)] {
+			if !isAncestor(decl, u) {
+				return true
+			}
+		}
+		return false
+	}
+	var unused []Unused
+	switch {
+	case member.MethodDeclaration() != nil:
+		m := member.MethodDeclaration().(*parser.MethodDeclarationContext)
+		if !referenced(m.Id().GetText(), m) {
+			unused = append(unused, Unused{Kind: UnusedMethod, Name: m.Id().GetText(), Node: m})
+		}
+		if m.Block() == nil || m.FormalParameters().FormalParameterList() == nil {
+			break
+		}
+		for _, p := range m.FormalParameters().FormalParameterList().AllFormalParameter() {
+			if references(p.Id().GetText(), m.Block(), uses, nil, false) == 0 {
+				unused = append(unused, Unused{Kind: UnusedParameter, Name: p.Id().GetText(), Node: p.(*parser.FormalParameterContext)})
+			}
+		}
+	case member.FieldDeclaration() != nil:
+		for _, v := range member.FieldDeclaration().VariableDeclarators().AllVariableDeclarator() {
+			if !referenced(v.Id().GetText(), v) {
+				unused = append(unused, Unused{Kind: UnusedField, Name: v.Id().GetText(), Node: v.(*parser.VariableDeclaratorContext)})
+			}
+		}
+	case member.PropertyDeclaration() != nil:
+		p := member.PropertyDeclaration().(*parser.PropertyDeclarationContext)
+		if !referenced(p.Id().GetText(), p) {
+			unused = append(unused, Unused{Kind: UnusedProperty, Name: p.Id().GetText(), Node: p})
+		}
+	}
+	return unused
+}
+
+// privateMember reports whether modifiers make a member private, and it
+// can't be used from outside the class in other ways.
+func privateMember(modifiers []parser.IModifierContext) bool {
+	for _, m := range modifiers {
+		switch {
+		case m.Annotation() != nil,
+			m.VIRTUAL() != nil, m.OVERRIDE() != nil, m.ABSTRACT() != nil,
+			m.TESTMETHOD() != nil, m.WEBSERVICE() != nil:
+			return false
+		}
+	}
+	access := AccessModifier(modifiers)
+	return access == "private" || access == ""
+}
+
+// usedName returns the lower-case name referenced by ctx if it is a
+// reference to a variable, field, property or method.
+func usedName(ctx antlr.ParserRuleContext) string {
+	switch c := ctx.(type) {
+	case *parser.IdPrimaryContext:
+		return strings.ToLower(c.Id().GetText())
+	case *parser.MethodCallContext:
+		if c.Id() != nil {
+			return strings.ToLower(c.Id().GetText())
+		}
+	case *parser.DotMethodCallContext:
+		return strings.ToLower(c.AnyId().GetText())
+	case *parser.DotExpressionContext:
+		if c.AnyId() != nil {
+			return strings.ToLower(c.AnyId().GetText())
+		}
+	}
+	return ""
+}
+
+var bindVariable = regexp.MustCompile(`:\s*([A-Za-z_][A-Za-z0-9_]*)`)
+
+// bindNames returns the lower-case names of variables that ctx, if it is a
+// string literal, may bind in a dynamic query passed to Database.query,
+// e.g. names in 'SELECT Id FROM Account WHERE Name IN :names'.
+func bindNames(ctx antlr.ParserRuleContext) []string {
+	literal, ok := ctx.(*parser.LiteralContext)
+	if !ok || literal.StringLiteral() == nil {
+		return nil
+	}
+	var names []string
+	for _, m := range bindVariable.FindAllStringSubmatch(literal.GetText(), -1) {
+		names = append(names, strings.ToLower(m[1]))
+	}
+	return names
+}
+
+// references returns the number of references to the variable name within
+// scope, ignoring those within removed declarations.  Assignments to the
+// variable are only counted if writes is set.
+func references(name string, scope antlr.Tree, uses map[string][]antlr.ParserRuleContext, removed map[antlr.ParserRuleContext]bool, writes bool) int {
+	n := 0
+	for _, u := range uses[strings.ToLower(name)] {
+		switch u.(type) {
+		case *parser.IdPrimaryContext:
+			if !writes && isAssigned(u) {
+				continue
+			}
+		case *parser.LiteralContext:
+		default:
+			continue
+		}
+		if !isAncestor(scope, u) {
+			continue
+		}
+		inRemoved := false
+		for p := u.GetParent(); p != nil && p != scope; p = p.GetParent() {
+			if ctx, ok := p.(antlr.ParserRuleContext); ok && removed[ctx] {
+				inRemoved = true
+				break
+			}
+		}
+		if !inRemoved {
+			n++
+		}
+	}
+	return n
+}
+
+// isAssigned reports whether the identifier is the target of a simple
+// assignment, which writes the variable without reading it.
+func isAssigned(id antlr.ParserRuleContext) bool {
+	primary := id.GetParent()
+	assign, ok := primary.GetParent().(*parser.AssignExpressionContext)
+	return ok && assign.ASSIGN() != nil && assign.Expression(0) == primary
+}
+
+// localScope returns the node containing the statements that can refer to
+// the local variable v.
+func localScope(v *parser.VariableDeclaratorContext) antlr.Tree {
+	declaration := false
+	for n := v.GetParent(); n != nil; n = n.GetParent() {
+		switch n.(type) {
+		case *parser.BlockContext, *parser.TriggerBlockContext:
+			return n
+		case *parser.StatementContext:
+			// the first statement is the declaration itself
+			if declaration {
+				return n
+			}
+			declaration = true
+		}
+	}
+	return nil
+}
+
+func isAncestor(ancestor antlr.Tree, node antlr.Tree) bool {
+	for n := node; n != nil; n = n.GetParent() {
+		if n == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// sideEffectFree reports whether evaluating e has no effects other than
+// producing its value: literals, variables, and new collections whose
+// contents are also side-effect free.
+func sideEffectFree(e parser.IExpressionContext) bool {
+	switch e := e.(type) {
+	case *parser.PrimaryExpressionContext:
+		switch e.Primary().(type) {
+		case *parser.LiteralPrimaryContext, *parser.IdPrimaryContext,
+			*parser.ThisPrimaryContext, *parser.TypeRefPrimaryContext:
+			return true
+		}
+	case *parser.SubExpressionContext:
+		return sideEffectFree(e.Expression())
+	case *parser.NewInstanceExpressionContext:
+		return sideEffectFreeCreator(e.Creator())
+	}
+	return false
+}
+
+func sideEffectFreeCreator(c parser.ICreatorContext) bool {
+	all := func(exprs []parser.IExpressionContext) bool {
+		for _, e := range exprs {
+			if !sideEffectFree(e) {
+				return false
+			}
+		}
+		return true
+	}
+	if c.ArrayCreatorRest() != nil {
+		a := c.ArrayCreatorRest()
+		if a.Expression() != nil && !sideEffectFree(a.Expression()) {
+			return false
+		}
+		return a.ArrayInitializer() == nil || all(a.ArrayInitializer().AllExpression())
+	}
+	// other constructors may have side effects
+	switch strings.ToLower(c.CreatedName().IdCreatedNamePair(0).AnyId().GetText()) {
+	case "list", "set", "map":
+	default:
+		return false
+	}
+	switch {
+	case c.ClassCreatorRest() != nil:
+		if args := c.ClassCreatorRest().Arguments().ExpressionList(); args != nil {
+			return all(args.AllExpression())
+		}
+		return true
+	case c.SetCreatorRest() != nil:
+		return all(c.SetCreatorRest().AllExpression())
+	case c.MapCreatorRest() != nil:
+		for _, p := range c.MapCreatorRest().AllMapCreatorRestPair() {
+			if !all(p.AllExpression()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// declarationHasComments reports whether the statement declaring the local
+// variable vd contains comments, is preceded by them or is followed by them
+// on the same line.  The comments would be lost or misplaced if vd were
+// removed.
+func (v *FormatVisitor) declarationHasComments(vd *parser.VariableDeclaratorContext) bool {
+	for n := vd.GetParent(); n != nil; n = n.GetParent() {
+		stmt, ok := n.(*parser.StatementContext)
+		if !ok {
+			continue
+		}
+		if v.hasComments(stmt) || len(v.tokens.GetHiddenTokensToLeft(stmt.GetStart().GetTokenIndex(), COMMENTS_CHANNEL)) > 0 {
+			return true
+		}
+		stop := stmt.GetStop()
+		for _, c := range v.tokens.GetHiddenTokensToRight(stop.GetTokenIndex(), COMMENTS_CHANNEL) {
+			if c.GetLine() == stop.GetLine() {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// isRemovedStatement reports whether stmt declares only local variables
+// removed by Options.RemoveUnusedLocals.
+func (v *FormatVisitor) isRemovedStatement(stmt parser.IStatementContext) bool {
+	decl := stmt.LocalVariableDeclarationStatement()
+	if len(v.removedLocals) == 0 || decl == nil {
+		return false
+	}
+	for _, vd := range decl.LocalVariableDeclaration().VariableDeclarators().AllVariableDeclarator() {
+		if !v.removedLocals[vd] {
+			return false
+		}
+	}
+	return true
+}
+
+// walk calls fn for every rule node in tree, in depth-first order.
+func walk(tree antlr.Tree, fn func(antlr.ParserRuleContext)) {
+	if ctx, ok := tree.(antlr.ParserRuleContext); ok {
+		fn(ctx)
+	}
+	for _, child := range tree.GetChildren() {
+		walk(child, fn)
+	}
+}
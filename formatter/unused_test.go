@@ -0,0 +1,181 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+const unusedSource = `public with sharing class Foo {
+	private Integer unusedField = 1;
+	private Integer usedField;
+	@TestVisible
+	private Integer visible;
+	private String name { get; set; }
+	public void run(String arg) {
+		Integer a = 1;
+		Integer b = a, c = usedField;
+		List<Account> accounts = new List<Account>();
+		Integer d = compute(arg);
+		Integer e;
+		e = 5;
+		Integer f = 2;
+		System.debug(f);
+	}
+	private Integer compute(String value, Integer extra) {
+		return value.length();
+	}
+	private void helper() {}
+}`
+
+func TestFindUnused(t *testing.T) {
+	input := antlr.NewInputStream(unusedSource)
+	lexer := parser.NewApexLexer(input)
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+	p := parser.NewApexParser(stream)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(&testErrorListener{t: t})
+
+	out := []string{}
+	for _, u := range FindUnused(p.CompilationUnit()) {
+		out = append(out, fmt.Sprintf("%d: %s %s %v", u.Node.GetStart().GetLine(), u.Kind, u.Name, u.Removable))
+	}
+	expected := []string{
+		"2: private field unusedField false",
+		"6: private property name false",
+		"8: local variable a true",
+		"9: local variable b true",
+		"9: local variable c true",
+		"10: local variable accounts true",
+		"11: local variable d false",
+		"12: local variable e false",
+		"17: parameter extra false",
+		"20: private method helper false",
+	}
+	if strings.Join(out, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected unused declarations.  expected:\n%s\ngot:\n%s\n", strings.Join(expected, "\n"), strings.Join(out, "\n"))
+	}
+}
+
+func TestRemoveUnusedLocals(t *testing.T) {
+	tests :=
+		[]struct {
+			input  string
+			output string
+		}{
+			{
+				unusedSource,
+				`public with sharing class Foo {
+	private Integer unusedField = 1;
+	private Integer usedField;
+	@TestVisible
+	private Integer visible;
+	private String name {get; set;}
+	public void run(String arg) {
+		Integer d = compute(arg);
+		Integer e;
+		e = 5;
+		Integer f = 2;
+		System.debug(f);
+	}
+	private Integer compute(String value, Integer extra) {
+		return value.length();
+	}
+	private void helper() {}
+}`},
+			{
+				`trigger T on Account (before insert) {
+	Integer unused = 1, count = 0;
+	System.debug(count);
+}`,
+				`trigger T on Account (before insert) {
+	Integer count = 0;
+	System.debug(count);
+}`},
+			{
+				`public class Foo {
+	public List<Account> find() {
+		Set<String> names = new Set<String>{'Acme'};
+		Integer limitCount = 10;
+		String soql = 'SELECT Id FROM Account WHERE Name IN :names LIMIT :limitCount';
+		Integer unused = 1;
+		return Database.query(soql);
+	}
+}`,
+				`public class Foo {
+	public List<Account> find() {
+		Set<String> names = new Set<String>{ 'Acme' };
+		Integer limitCount = 10;
+		String soql = 'SELECT Id FROM Account WHERE Name IN :names LIMIT :limitCount';
+		return Database.query(soql);
+	}
+}`},
+			{
+				`public class Foo {
+	public void run(Boolean b) {
+		if (b) {
+			Integer inner = 1;
+		}
+		for (Integer i = 0; i < 3; i++) {
+			Integer x = i;
+			System.debug(x);
+		}
+	}
+}`,
+				`public class Foo {
+	public void run(Boolean b) {
+		if (b) {}
+		for (Integer i = 0; i < 3; i++) {
+			Integer x = i;
+			System.debug(x);
+		}
+	}
+}`},
+			{
+				`public class Foo {
+	public void run() {
+		Integer a = 1;
+		// keep me
+		Integer e = a;
+		Integer b = 2; /* and me */
+		Integer d = 4; // and me too
+		System.debug('done');
+	}
+}`,
+				`public class Foo {
+	public void run() {
+		Integer a = 1;
+		// keep me
+		Integer e = a;
+		Integer b = 2;
+		/* and me */
+		Integer d = 4;
+		// and me too
+		System.debug('done');
+	}
+}`},
+		}
+	for _, tt := range tests {
+		input := antlr.NewInputStream(tt.input)
+		lexer := parser.NewApexLexer(input)
+		stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+		p := parser.NewApexParser(stream)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(&testErrorListener{t: t})
+
+		v := NewFormatVisitor(stream)
+		v.options = Options{RemoveUnusedLocals: true}
+		out, ok := v.visitRule(p.CompilationUnit()).(string)
+		if !ok {
+			t.Errorf("Unexpected result parsing apex")
+		}
+		if out != tt.output {
+			t.Errorf("unexpected format.  expected:\n%s\ngot:\n%s\n", tt.output, out)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package formatter
 import (
 	"bufio"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -19,7 +20,11 @@ type FormatVisitor struct {
 	commentsOutput map[int]struct{}
 	newlinesOutput map[int]struct{}
 	parser.BaseApexParserVisitor
-	wrap bool
+	wrap    bool
+	options Options
+	// removedLocals holds the variable declarators deleted by
+	// Options.RemoveUnusedLocals.
+	removedLocals map[antlr.ParserRuleContext]bool
 }
 
 func NewFormatVisitor(tokens *antlr.CommonTokenStream) *FormatVisitor {
@@ -46,56 +51,137 @@ func (v *FormatVisitor) visitRule(node antlr.RuleNode) interface{} {
 		panic(fmt.Sprintf("MISSING VISIT FUNCTION FOR %T", node))
 	}
 	if beforeComments != nil {
-		comments := []string{}
-		for _, c := range beforeComments {
-			if _, seen := v.commentsOutput[c.GetTokenIndex()]; !seen {
-				comments = append(comments, cleanWhitespace(c.GetText()))
-				v.commentsOutput[c.GetTokenIndex()] = struct{}{}
-			}
-		}
-		if len(comments) > 0 {
+		if comments := v.pendingComments(beforeComments); len(comments) > 0 {
 			result = fmt.Sprintf("%s\n%s", strings.Join(comments, "\n"), result)
 		}
 	}
 	if beforeWhitespace != nil {
-		injectNewline := false
+		blankLines := 0
 		for _, c := range beforeWhitespace {
-			if len(strings.Split(c.GetText(), "\n")) > 2 {
+			if n := strings.Count(c.GetText(), "\n") - 1; n > 0 {
 				if _, seen := v.newlinesOutput[c.GetTokenIndex()]; !seen {
 					v.newlinesOutput[c.GetTokenIndex()] = struct{}{}
-					injectNewline = true
+					blankLines = max(blankLines, min(n, v.maxBlankLines()))
 				}
 			}
 		}
-		if injectNewline {
-			result = fmt.Sprintf("\n%s", result)
+		if blankLines > 0 {
+			result = fmt.Sprintf("%s%s", strings.Repeat("\n", blankLines), result)
 		}
 	}
 	return result
 }
 
+// pendingComments returns the formatted comments among tokens that have not
+// already been output, and marks them as output.  Comments other than
+// ApexDoc comments are dropped if Options.StripComments is set.
+func (v *FormatVisitor) pendingComments(tokens []antlr.Token) []string {
+	comments := []string{}
+	for _, c := range tokens {
+		if _, seen := v.commentsOutput[c.GetTokenIndex()]; seen {
+			continue
+		}
+		v.commentsOutput[c.GetTokenIndex()] = struct{}{}
+		if v.options.StripComments && !IsDocComment(c.GetText()) {
+			continue
+		}
+		comment := v.formatComment(c.GetText())
+		if v.options.Compact && c.GetTokenType() == parser.ApexLexerLINE_COMMENT {
+			// a line comment must still end its line
+			comment += "\n"
+		}
+		comments = append(comments, v.verbatim(comment))
+	}
+	return comments
+}
+
+// commentsBefore returns the formatted comments preceding a closing token,
+// such as the brace ending a body, that have not already been output.
+func (v *FormatVisitor) commentsBefore(token antlr.TerminalNode) []string {
+	return v.pendingComments(v.tokens.GetHiddenTokensToLeft(token.GetSymbol().GetTokenIndex(), COMMENTS_CHANNEL))
+}
+
+// sameLineComments returns the formatted comments following ctx on the
+// line where it ends that have not already been output.
+func (v *FormatVisitor) sameLineComments(ctx antlr.ParserRuleContext) []string {
+	stop := ctx.GetStop()
+	var tokens []antlr.Token
+	for _, c := range v.tokens.GetHiddenTokensToRight(stop.GetTokenIndex(), COMMENTS_CHANNEL) {
+		if c.GetLine() != stop.GetLine() {
+			break
+		}
+		tokens = append(tokens, c)
+	}
+	return v.pendingComments(tokens)
+}
+
+// maxBlankLines returns the number of consecutive blank lines to preserve.
+func (v *FormatVisitor) maxBlankLines() int {
+	if v.options.MaxBlankLines == nil {
+		return DefaultMaxBlankLines
+	}
+	return max(*v.options.MaxBlankLines, 0)
+}
+
 func (v *FormatVisitor) Modifiers(ctxs []parser.IModifierContext) string {
 	mods := []string{}
 	annotations := []string{}
+	inline := []string{}
 	for _, m := range ctxs {
-		if m.Annotation() != nil {
-			annotations = append(annotations, v.visitRule(m.Annotation()).(string))
+		if a := m.Annotation(); a != nil {
+			if v.isInlineAnnotation(a.QualifiedName().GetText(), a.LPAREN() != nil) {
+				inline = append(inline, v.visitRule(a).(string))
+			} else {
+				annotations = append(annotations, v.visitRule(a).(string))
+			}
 		} else {
+			words := []string{}
 			for _, word := range m.GetChildren() {
-				mods = append(mods, word.(antlr.TerminalNode).GetText())
+				words = append(words, word.(antlr.TerminalNode).GetText())
 			}
+			mods = append(mods, strings.Join(words, " "))
 		}
 	}
+	if v.options.SortModifiers {
+		sort.SliceStable(mods, func(i, j int) bool {
+			return rankModifier(mods[i]) < rankModifier(mods[j])
+		})
+	}
 	var m strings.Builder
 	if len(annotations) > 0 {
 		m.WriteString(strings.Join(annotations, "\n") + "\n")
 	}
+	if len(inline) > 0 {
+		m.WriteString(strings.Join(inline, " ") + " ")
+	}
 	if len(mods) > 0 {
 		m.WriteString(strings.Join(mods, " ") + " ")
 	}
 	return m.String()
 }
 
+// maxWidth returns the line length beyond which lists are wrapped.
+func (v *FormatVisitor) maxWidth() int {
+	if v.options.MaxWidth > 0 {
+		return v.options.MaxWidth
+	}
+	return DefaultMaxWidth
+}
+
+// fits reports whether line, printed at the indentation of ctx, is within
+// the maximum width.
+func (v *FormatVisitor) fits(ctx antlr.Tree, line string) bool {
+	depth := 0
+	for p := ctx.GetParent(); p != nil; p = p.GetParent() {
+		switch p.(type) {
+		case *parser.ClassBodyContext, *parser.InterfaceBodyContext,
+			*parser.BlockContext, *parser.TriggerBlockContext:
+			depth++
+		}
+	}
+	return depth*TabWidth+len(line) <= v.maxWidth()
+}
+
 func (v *FormatVisitor) indent(text string) string {
 	return v.indentTo(text, 1)
 }
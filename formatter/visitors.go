@@ -2,6 +2,7 @@ package formatter
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -10,24 +11,36 @@ import (
 )
 
 func (v *FormatVisitor) VisitCompilationUnit(ctx *parser.CompilationUnitContext) interface{} {
+	if !v.options.Compact {
+		return v.compilationUnit(ctx)
+	}
+	v.protectLiterals()
+	// take leading comments here so that their layout is compacted too
+	comments := v.pendingComments(v.tokens.GetHiddenTokensToLeft(ctx.GetStart().GetTokenIndex(), COMMENTS_CHANNEL))
+	return compactLayout(strings.Join(append(comments, v.compilationUnit(ctx)), "\n"))
+}
+
+func (v *FormatVisitor) compilationUnit(ctx *parser.CompilationUnitContext) string {
+	if v.options.RemoveUnusedLocals {
+		v.removedLocals = make(map[antlr.ParserRuleContext]bool)
+		// keep declarations with comments so that the comments aren't lost
+		for _, u := range findUnused(ctx, v.declarationHasComments) {
+			if u.Removable {
+				v.removedLocals[u.Node] = true
+			}
+		}
+	}
 	if trigger := ctx.TriggerUnit(); trigger != nil {
-		return v.visitRule(trigger)
+		return v.visitRule(trigger).(string)
 	}
 	t := ctx.TypeDeclaration()
 	switch {
 	case t.ClassDeclaration() != nil:
-		return fmt.Sprintf("%s%s", v.Modifiers(t.AllModifier()), v.visitRule(t.ClassDeclaration()).(string))
+		return v.withDocStub(t, nil, false, fmt.Sprintf("%s%s", v.Modifiers(t.AllModifier()), v.visitRule(t.ClassDeclaration()).(string)))
 	case t.InterfaceDeclaration() != nil:
-		return fmt.Sprintf("%s%s", v.Modifiers(t.AllModifier()), v.visitRule(t.InterfaceDeclaration()).(string))
+		return v.withDocStub(t, nil, false, fmt.Sprintf("%s%s", v.Modifiers(t.AllModifier()), v.visitRule(t.InterfaceDeclaration()).(string)))
 	case t.EnumDeclaration() != nil:
-		enum := t.EnumDeclaration()
-		constants := []string{}
-		if enum.EnumConstants() != nil {
-			for _, e := range enum.EnumConstants().AllId() {
-				constants = append(constants, e.GetText())
-			}
-		}
-		return fmt.Sprintf("enum %s {%s}", v.visitRule(enum.Id()), strings.Join(constants, ", "))
+		return v.withDocStub(t, nil, false, fmt.Sprintf("%s%s", v.Modifiers(t.AllModifier()), v.visitRule(t.EnumDeclaration()).(string)))
 	}
 	return ""
 }
@@ -42,26 +55,59 @@ func (v *FormatVisitor) VisitClassDeclaration(ctx *parser.ClassDeclarationContex
 		class.WriteString(fmt.Sprintf(" implements %s", v.visitRule(ctx.TypeList())))
 	}
 	if ctx.ClassBody().GetText() == "{}" {
-		class.WriteString(" {}")
+		class.WriteString(v.declarationBody("{}"))
 	} else {
-		class.WriteString(fmt.Sprintf(" {\n%s\n}", v.indent(v.visitRule(ctx.ClassBody()).(string))))
+		class.WriteString(v.declarationBody(fmt.Sprintf("{\n%s\n}", v.indent(v.visitRule(ctx.ClassBody()).(string)))))
 	}
 	return class.String()
 }
 
 func (v *FormatVisitor) VisitTriggerUnit(ctx *parser.TriggerUnitContext) interface{} {
+	cases := ctx.AllTriggerCase()
+	if v.options.SortTriggerEvents {
+		sort.SliceStable(cases, func(i, j int) bool {
+			return triggerCaseOrder(cases[i]) < triggerCaseOrder(cases[j])
+		})
+	}
 	triggerCases := []string{}
-	for _, t := range ctx.AllTriggerCase() {
+	for _, t := range cases {
 		triggerCases = append(triggerCases, v.visitRule(t).(string))
 	}
+	header := fmt.Sprintf("trigger %s on %s (%s) {", v.visitRule(ctx.Id(0)), v.visitRule(ctx.Id(1)), strings.Join(triggerCases, ", "))
+	if !v.fits(ctx, header) {
+		return fmt.Sprintf("trigger %s on %s (\n%s\n) %s", v.visitRule(ctx.Id(0)), v.visitRule(ctx.Id(1)),
+			v.indent(strings.Join(triggerCases, ",\n")),
+			v.visitRule(ctx.TriggerBlock()))
+	}
 	return fmt.Sprintf("trigger %s on %s (%s) %s", v.visitRule(ctx.Id(0)), v.visitRule(ctx.Id(1)),
 		strings.Join(triggerCases, ", "),
 		v.visitRule(ctx.TriggerBlock()))
 }
 
+// triggerCaseOrder ranks trigger events canonically: before events, then
+// after events, each in insert, update, delete, undelete order.
+func triggerCaseOrder(ctx parser.ITriggerCaseContext) int {
+	order := 0
+	if ctx.AFTER() != nil {
+		order = 4
+	}
+	switch {
+	case ctx.UPDATE() != nil:
+		order += 1
+	case ctx.DELETE() != nil:
+		order += 2
+	case ctx.UNDELETE() != nil:
+		order += 3
+	}
+	return order
+}
+
 func (v *FormatVisitor) VisitTriggerBlock(ctx *parser.TriggerBlockContext) interface{} {
 	statements := []string{}
 	for _, stmt := range ctx.AllTriggerStatement() {
+		if stmt.Statement() != nil && v.isRemovedStatement(stmt.Statement()) {
+			continue
+		}
 		statements = append(statements, v.visitRule(stmt).(string))
 	}
 	return fmt.Sprintf("{\n%s\n}", v.indent(strings.Join(statements, "\n")))
@@ -76,27 +122,51 @@ func (v *FormatVisitor) VisitTriggerCase(ctx *parser.TriggerCaseContext) interfa
 }
 
 func (v *FormatVisitor) VisitEnumDeclaration(ctx *parser.EnumDeclarationContext) interface{} {
-	enumConstants := ""
+	constants := []string{}
 	if ctx.EnumConstants() != nil {
-		enumConstants = v.visitRule(ctx.EnumConstants()).(string)
+		for _, id := range ctx.EnumConstants().AllId() {
+			constants = append(constants, v.visitRule(id).(string))
+		}
 	}
-	return fmt.Sprintf("enum %s { %s }", v.visitRule(ctx.Id()), enumConstants)
-}
-
-func (v *FormatVisitor) VisitEnumConstants(ctx *parser.EnumConstantsContext) interface{} {
-	ids := []string{}
-	for _, t := range ctx.AllId() {
-		ids = append(ids, t.GetText())
+	// Comments after the last constant would otherwise be lost.
+	trailing := v.commentsBefore(ctx.RBRACE())
+	name := v.visitRule(ctx.Id()).(string)
+	if len(constants) == 0 && len(trailing) == 0 {
+		return fmt.Sprintf("enum %s {}", name)
 	}
-	return strings.Join(ids, ", ")
+	oneLine := fmt.Sprintf("enum %s { %s }", name, strings.Join(constants, ", "))
+	if len(trailing) == 0 && !strings.Contains(oneLine, "\n") && v.fits(ctx, oneLine) {
+		return oneLine
+	}
+	body := strings.Join(constants, ",\n")
+	if len(trailing) > 0 {
+		body = strings.TrimPrefix(body+"\n"+strings.Join(trailing, "\n"), "\n")
+	}
+	return fmt.Sprintf("enum %s {\n%s\n}", name, v.indent(body))
 }
 
 func (v *FormatVisitor) VisitInterfaceDeclaration(ctx *parser.InterfaceDeclarationContext) interface{} {
-	extends := ""
+	var iface strings.Builder
+	iface.WriteString(fmt.Sprintf("interface %s", v.visitRule(ctx.Id())))
 	if ctx.EXTENDS() != nil {
-		extends = fmt.Sprintf(" extends %s ", v.visitRule(ctx.TypeList()))
+		types := []string{}
+		for _, t := range ctx.TypeList().AllTypeRef() {
+			types = append(types, v.visitRule(t).(string))
+		}
+		extends := fmt.Sprintf(" extends %s", strings.Join(types, ", "))
+		if len(types) > 1 && !v.fits(ctx, iface.String()+extends+" {") {
+			// indent twice to set the types apart from the members
+			extends = fmt.Sprintf(" extends\n%s", v.indent(v.indent(strings.Join(types, ",\n"))))
+		}
+		iface.WriteString(extends)
+	}
+	body := v.visitRule(ctx.InterfaceBody()).(string)
+	if body == "" {
+		iface.WriteString(v.declarationBody("{}"))
+	} else {
+		iface.WriteString(v.declarationBody(fmt.Sprintf("{\n%s\n}", v.indent(body))))
 	}
-	return fmt.Sprintf("interface %s%s {\n%s\n}", ctx.Id().GetText(), extends, v.indent(v.visitRule(ctx.InterfaceBody()).(string)))
+	return iface.String()
 }
 
 func (v *FormatVisitor) VisitInterfaceBody(ctx *parser.InterfaceBodyContext) interface{} {
@@ -104,13 +174,29 @@ func (v *FormatVisitor) VisitInterfaceBody(ctx *parser.InterfaceBodyContext) int
 	for _, d := range ctx.AllInterfaceMethodDeclaration() {
 		declarations = append(declarations, v.visitRule(d).(string))
 	}
+	// Comments after the last method would otherwise be lost.
+	declarations = append(declarations, v.commentsBefore(ctx.RBRACE())...)
 	return strings.Join(declarations, "\n")
 }
 
 func (v *FormatVisitor) VisitClassBody(ctx *parser.ClassBodyContext) interface{} {
 	var cb []string
-	for _, b := range ctx.AllClassBodyDeclaration() {
-		cb = append(cb, v.visitRule(b).(string))
+	decls := ctx.AllClassBodyDeclaration()
+	for _, b := range decls {
+		text := v.visitRule(b).(string)
+		if v.options.SortMembers {
+			// keep trailing comments with their member when it moves
+			if trailing := v.sameLineComments(b); len(trailing) > 0 {
+				text += " " + strings.Join(trailing, " ")
+			}
+		}
+		cb = append(cb, text)
+	}
+	if v.options.SortMembers {
+		decls, cb = v.sortMembers(decls, cb)
+	}
+	if v.options.SeparateMembers {
+		cb = separateMembers(decls, cb)
 	}
 	return strings.Join(cb, "\n")
 }
@@ -126,7 +212,15 @@ func (v *FormatVisitor) VisitClassBodyDeclaration(ctx *parser.ClassBodyDeclarati
 		}
 		return fmt.Sprintf("%s%s", static, v.visitRule(ctx.Block()).(string))
 	case ctx.MemberDeclaration() != nil:
-		return fmt.Sprintf("%s%s", v.Modifiers(ctx.AllModifier()), v.visitRule(ctx.MemberDeclaration()))
+		declaration := fmt.Sprintf("%s%s", v.Modifiers(ctx.AllModifier()), v.visitRule(ctx.MemberDeclaration()))
+		switch m := ctx.MemberDeclaration(); {
+		case m.MethodDeclaration() != nil:
+			method := m.MethodDeclaration()
+			return v.withDocStub(ctx, method.FormalParameters(), method.TypeRef() != nil, declaration)
+		case m.ClassDeclaration() != nil, m.InterfaceDeclaration() != nil, m.EnumDeclaration() != nil:
+			return v.withDocStub(ctx, nil, false, declaration)
+		}
+		return declaration
 	}
 	return ""
 }
@@ -140,7 +234,8 @@ func (v *FormatVisitor) VisitInterfaceMethodDeclaration(ctx *parser.InterfaceMet
 	if ctx.TypeRef() != nil {
 		returnType = v.visitRule(ctx.TypeRef()).(string)
 	}
-	return fmt.Sprintf("%s%s %s%s;", v.Modifiers(ctx.AllModifier()), returnType, ctx.Id().GetText(), v.visitRule(ctx.FormalParameters()))
+	declaration := fmt.Sprintf("%s%s %s%s;", v.Modifiers(ctx.AllModifier()), returnType, ctx.Id().GetText(), v.visitRule(ctx.FormalParameters()))
+	return v.withDocStub(ctx, ctx.FormalParameters(), ctx.TypeRef() != nil, declaration)
 }
 
 func (v *FormatVisitor) VisitFieldDeclaration(ctx *parser.FieldDeclarationContext) interface{} {
@@ -190,18 +285,25 @@ func (v *FormatVisitor) VisitSetter(ctx *parser.SetterContext) interface{} {
 }
 
 func (v *FormatVisitor) VisitConstructorDeclaration(ctx *parser.ConstructorDeclarationContext) interface{} {
-	return fmt.Sprintf("%s%s %s", v.visitRule(ctx.QualifiedName()), v.visitRule(ctx.FormalParameters()), v.visitRule(ctx.Block()).(string))
+	return fmt.Sprintf("%s%s%s", v.visitRule(ctx.QualifiedName()), v.visitRule(ctx.FormalParameters()), v.declarationBody(v.visitRule(ctx.Block()).(string)))
 }
 
 func (v *FormatVisitor) VisitBlock(ctx *parser.BlockContext) interface{} {
 	statements := []string{}
 	for _, stmt := range ctx.AllStatement() {
+		if v.isRemovedStatement(stmt) {
+			continue
+		}
 		statements = append(statements, v.visitRule(stmt).(string))
 	}
 	if len(statements) == 0 {
 		return "{}"
 	}
-	return fmt.Sprintf("{\n%s\n}", v.indent(strings.Join(statements, "\n")))
+	body := strings.Join(statements, "\n")
+	if v.options.TrimBlocks {
+		body = strings.Trim(body, "\n")
+	}
+	return fmt.Sprintf("{\n%s\n}", v.indent(body))
 }
 
 func (v *FormatVisitor) VisitStatement(ctx *parser.StatementContext) interface{} {
@@ -214,6 +316,14 @@ func (v *FormatVisitor) VisitBlockMemberDeclaration(ctx *parser.BlockMemberDecla
 }
 
 func (v *FormatVisitor) VisitIfStatement(ctx *parser.IfStatementContext) interface{} {
+	if v.options.Simplify {
+		if r, ok := v.simplifyIfReturn(ctx); ok {
+			return r
+		}
+	}
+	if v.options.KeepSingleLineGuards && isSingleLineGuard(ctx) {
+		return fmt.Sprintf("if %s %s", v.visitRule(ctx.ParExpression()), v.visitRule(ctx.Statement(0)))
+	}
 	var out strings.Builder
 	if block := ctx.Statement(0).Block(); block != nil {
 		out.WriteString(fmt.Sprintf("if %s %s", v.visitRule(ctx.ParExpression()),
@@ -222,11 +332,15 @@ func (v *FormatVisitor) VisitIfStatement(ctx *parser.IfStatementContext) interfa
 		out.WriteString(fmt.Sprintf("if %s {\n%s\n}", v.visitRule(ctx.ParExpression()),
 			v.indent(v.visitRule(ctx.Statement(0)).(string))))
 	}
-	if ctx.ELSE() != nil {
+	if ctx.ELSE() != nil && !(v.options.Simplify && isEmptyBlock(ctx.Statement(1)) && !v.hasComments(ctx.Statement(1))) {
 		if block := ctx.Statement(1).Block(); block != nil {
 			out.WriteString(fmt.Sprintf(" else %s", v.visitRule(ctx.Statement(1)).(string)))
 		} else if ifStatement := ctx.Statement(1).IfStatement(); ifStatement != nil {
-			out.WriteString(fmt.Sprintf(" else %s", v.visitRule(ifStatement)))
+			if r, ok := v.simplifyElseIf(ifStatement.(*parser.IfStatementContext)); ok {
+				out.WriteString(fmt.Sprintf(" else {\n%s\n}", v.indent(r)))
+			} else {
+				out.WriteString(fmt.Sprintf(" else %s", v.visitRule(ifStatement)))
+			}
 		} else {
 			out.WriteString(fmt.Sprintf(" else {\n%s}", v.indent(v.visitRule(ctx.Statement(1)).(string))))
 		}
@@ -519,6 +633,9 @@ func (v *FormatVisitor) VisitPostOpExpression(ctx *parser.PostOpExpressionContex
 }
 
 func (v *FormatVisitor) VisitSubExpression(ctx *parser.SubExpressionContext) interface{} {
+	if v.options.Simplify && redundantParens(ctx) {
+		return v.visitRule(ctx.Expression())
+	}
 	return fmt.Sprintf("(%s)", v.visitRule(ctx.Expression()))
 }
 
@@ -535,6 +652,12 @@ func (v *FormatVisitor) VisitArrayExpression(ctx *parser.ArrayExpressionContext)
 }
 
 func (v *FormatVisitor) VisitDotExpression(ctx *parser.DotExpressionContext) interface{} {
+	if v.options.Simplify && redundantThis(ctx) {
+		if m := ctx.DotMethodCall(); m != nil {
+			return v.visitRule(m)
+		}
+		return v.visitRule(ctx.AnyId())
+	}
 	i := NewChainVisitor()
 	depth := i.visitRule(ctx.Expression()).(int)
 	log.Debug(fmt.Sprintf("depth is %d: %s", depth, ctx.GetText()))
@@ -1084,22 +1207,40 @@ func (v *FormatVisitor) VisitValueList(ctx *parser.ValueListContext) interface{}
 }
 
 func (v *FormatVisitor) VisitGroupByClause(ctx *parser.GroupByClauseContext) interface{} {
-	fieldNames := []string{}
-	for _, i := range ctx.AllFieldName() {
-		fieldNames = append(fieldNames, v.visitRule(i).(string))
+	sep := " "
+	indent := 0
+	if v.wrap {
+		sep = "\n"
+		indent = 1
 	}
+	var clause strings.Builder
+	clause.WriteString("GROUP BY")
 	switch {
-	case ctx.ROLLUP() != nil:
-		return fmt.Sprintf("GROUP BY ROLLUP (%s)", strings.Join(fieldNames, ", "))
-	case ctx.CUBE() != nil:
-		return fmt.Sprintf("GROUP BY CUBE (%s)", strings.Join(fieldNames, ", "))
+	case ctx.ROLLUP() != nil, ctx.CUBE() != nil:
+		fieldNames := []string{}
+		for _, i := range ctx.AllFieldName() {
+			fieldNames = append(fieldNames, v.visitRule(i).(string))
+		}
+		function := "ROLLUP"
+		if ctx.CUBE() != nil {
+			function = "CUBE"
+		}
+		if v.wrap && len(fieldNames) > 1 {
+			clause.WriteString(fmt.Sprintf(" %s(\n%s\n)", function, v.indent(strings.Join(fieldNames, ",\n"))))
+		} else {
+			clause.WriteString(fmt.Sprintf(" %s(%s)", function, strings.Join(fieldNames, ", ")))
+		}
 	default:
-		having := ""
+		clause.WriteString(sep)
+		clause.WriteString(v.indentTo(v.visitRule(ctx.SelectList()).(string), indent))
 		if l := ctx.LogicalExpression(); l != nil {
-			having = fmt.Sprintf("HAVING %s", v.visitRule(l))
+			clause.WriteString(sep)
+			clause.WriteString("HAVING")
+			clause.WriteString(sep)
+			clause.WriteString(v.indentTo(v.visitRule(l).(string), indent))
 		}
-		return fmt.Sprintf("GROUP BY %s%s", v.visitRule(ctx.SelectList()), having)
 	}
+	return clause.String()
 }
 
 func (v *FormatVisitor) VisitUsingScope(ctx *parser.UsingScopeContext) interface{} {
@@ -1259,6 +1400,11 @@ func (v *FormatVisitor) VisitCmpExpression(ctx *parser.CmpExpressionContext) int
 
 func (v *FormatVisitor) VisitEqualityExpression(ctx *parser.EqualityExpressionContext) interface{} {
 	defer restoreWrap(unwrap(v))
+	if v.options.Simplify {
+		if s, ok := v.simplifyBooleanComparison(ctx); ok {
+			return s
+		}
+	}
 	cmpToken := ctx.GetChild(1).(antlr.TerminalNode).GetText()
 	return fmt.Sprintf("%s %s %s", v.visitRule(ctx.Expression(0)), cmpToken, v.visitRule(ctx.Expression(1)))
 }
@@ -1311,7 +1457,11 @@ func (v *FormatVisitor) VisitAnnotation(ctx *parser.AnnotationContext) interface
 		}
 		args = fmt.Sprintf("(%s)", vals)
 	}
-	return fmt.Sprintf("@%s%s", v.visitRule(ctx.QualifiedName()), args)
+	name := v.visitRule(ctx.QualifiedName()).(string)
+	if v.options.NormalizeAnnotations {
+		name = normalizeAnnotation(name)
+	}
+	return fmt.Sprintf("@%s%s", name, args)
 }
 
 func (v *FormatVisitor) VisitElementValuePairs(ctx *parser.ElementValuePairsContext) interface{} {
@@ -1365,6 +1515,9 @@ func (v *FormatVisitor) VisitQualifiedName(ctx *parser.QualifiedNameContext) int
 func (v *FormatVisitor) VisitVariableDeclarators(ctx *parser.VariableDeclaratorsContext) interface{} {
 	vars := []string{}
 	for _, vd := range ctx.AllVariableDeclarator() {
+		if v.removedLocals[vd] {
+			continue
+		}
 		vars = append(vars, v.visitRule(vd).(string))
 	}
 	return strings.Join(vars, ", ")
@@ -1388,7 +1541,7 @@ func (v *FormatVisitor) VisitMethodDeclaration(ctx *parser.MethodDeclarationCont
 	}
 	body := ";"
 	if ctx.Block() != nil {
-		body = " " + v.visitRule(ctx.Block()).(string)
+		body = v.declarationBody(v.visitRule(ctx.Block()).(string))
 	}
 	return fmt.Sprintf("%s %s%s%s", returnType, ctx.Id().GetText(),
 		v.visitRule(ctx.FormalParameters()),
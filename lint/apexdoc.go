@@ -0,0 +1,56 @@
+package lint
+
+import (
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+type missingApexDoc struct{}
+
+func (r *missingApexDoc) Name() string {
+	return "missing-apexdoc"
+}
+
+func (r *missingApexDoc) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	report := func(ctx antlr.ParserRuleContext, access, kind, name string) {
+		if (access == "global" || access == "public") && !formatter.HasDocComment(tokens, ctx) {
+			diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "%s %s %s has no ApexDoc comment", access, kind, name))
+		}
+	}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		switch c := ctx.(type) {
+		case *parser.TypeDeclarationContext:
+			kind, name := typeName(c.ClassDeclaration(), c.InterfaceDeclaration(), c.EnumDeclaration())
+			report(c, formatter.AccessModifier(c.AllModifier()), kind, name)
+		case *parser.ClassBodyDeclarationContext:
+			member := c.MemberDeclaration()
+			if member == nil {
+				return
+			}
+			if m := member.MethodDeclaration(); m != nil {
+				report(c, formatter.AccessModifier(c.AllModifier()), "method", m.Id().GetText())
+				return
+			}
+			if kind, name := typeName(member.ClassDeclaration(), member.InterfaceDeclaration(), member.EnumDeclaration()); kind != "" {
+				report(c, formatter.AccessModifier(c.AllModifier()), kind, name)
+			}
+		case *parser.InterfaceMethodDeclarationContext:
+			report(c, formatter.InterfaceAccess(c), "method", c.Id().GetText())
+		}
+	})
+	return diagnostics
+}
+
+func typeName(class parser.IClassDeclarationContext, iface parser.IInterfaceDeclarationContext, enum parser.IEnumDeclarationContext) (string, string) {
+	switch {
+	case class != nil:
+		return "class", class.Id().GetText()
+	case iface != nil:
+		return "interface", iface.Id().GetText()
+	case enum != nil:
+		return "enum", enum.Id().GetText()
+	}
+	return "", ""
+}
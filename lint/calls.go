@@ -0,0 +1,244 @@
+package lint
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/ast"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Project summarizes the methods declared in a set of Apex files so that
+// the soql-in-loop and dml-in-loop rules can follow calls from a loop into
+// methods that query or modify records.  Calls are resolved by name: calls
+// to methods of the same class, of enclosing classes, and static calls
+// qualified with a class name are followed, but calls on instances are not.
+type Project struct {
+	methods map[string]*methodSummary
+}
+
+// NewProject returns an empty Project.
+func NewProject() *Project {
+	return &Project{methods: make(map[string]*methodSummary)}
+}
+
+// effect is a query or DML operation performed by a method.
+type effect struct {
+	what string
+	file string
+	line int
+}
+
+const (
+	soqlEffect = iota
+	dmlEffect
+)
+
+// methodSummary describes the methods with a given name in a class.
+// Overloads are not distinguished.
+type methodSummary struct {
+	direct [2]*effect
+	calls  [][]string
+}
+
+// Add parses an Apex file and adds its methods to the project.  reader is
+// used if filename is empty.
+func (p *Project) Add(filename string, reader io.Reader) error {
+	tree, _, err := formatter.Parse(filename, reader)
+	if err != nil {
+		return err
+	}
+	p.addTree(filename, tree)
+	return nil
+}
+
+func (p *Project) addTree(filename string, tree antlr.ParseTree) {
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		m, ok := ctx.(*parser.MethodDeclarationContext)
+		if !ok || m.Block() == nil {
+			return
+		}
+		scope := enclosingScope(m)
+		key := strings.ToLower(scope + "." + m.Id().GetText())
+		summary, ok := p.methods[key]
+		if !ok {
+			summary = &methodSummary{}
+			p.methods[key] = summary
+		}
+		inspect(m.Block(), func(ctx antlr.ParserRuleContext) {
+			if kind, what := operation(ctx); what != "" && summary.direct[kind] == nil {
+				summary.direct[kind] = &effect{what: what, file: filename, line: ctx.GetStart().GetLine()}
+			}
+			if candidates := callTargets(ctx, scope); candidates != nil {
+				summary.calls = append(summary.calls, candidates)
+			}
+		})
+	})
+}
+
+// effect returns the first query or DML operation of the given kind
+// performed by a method in candidates, directly or through the methods it
+// calls.
+func (p *Project) effect(candidates []string, kind int, visiting map[string]bool) *effect {
+	for _, key := range candidates {
+		summary, ok := p.methods[key]
+		if !ok {
+			continue
+		}
+		if summary.direct[kind] != nil {
+			return summary.direct[kind]
+		}
+		if visiting[key] {
+			return nil
+		}
+		visiting[key] = true
+		for _, call := range summary.calls {
+			if e := p.effect(call, kind, visiting); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// operation reports whether ctx is a query or DML operation, and describes
+// it.
+func operation(ctx antlr.ParserRuleContext) (int, string) {
+	switch ctx.(type) {
+	case *parser.SoqlLiteralContext:
+		return soqlEffect, "SOQL query"
+	case *parser.SoslLiteralContext:
+		return soqlEffect, "SOSL search"
+	}
+	if isDMLStatement(ctx) {
+		return dmlEffect, "DML " + strings.ToLower(ctx.GetStart().GetText())
+	}
+	dot, ok := ctx.(*parser.DotExpressionContext)
+	if !ok || dot.DotMethodCall() == nil {
+		return 0, ""
+	}
+	receiver := dot.Expression().GetText()
+	method := dot.DotMethodCall().AnyId().GetText()
+	switch ast.Operation(receiver, method) {
+	case ast.QueryOperation:
+		return soqlEffect, receiver + "." + method + " call"
+	case ast.DMLOperation:
+		return dmlEffect, receiver + "." + method + " call"
+	}
+	return 0, ""
+}
+
+// callTargets returns the keys of the methods that a call in scope may
+// refer to, most specific first, or nil if ctx is not a call that can be
+// resolved by name.
+func callTargets(ctx antlr.ParserRuleContext, scope string) []string {
+	var qualifier, name string
+	switch e := ctx.(type) {
+	case *parser.MethodCallExpressionContext:
+		if e.MethodCall().Id() == nil {
+			return nil
+		}
+		name = e.MethodCall().Id().GetText()
+	case *parser.DotExpressionContext:
+		if e.DotMethodCall() == nil {
+			return nil
+		}
+		name = e.DotMethodCall().AnyId().GetText()
+		if primary, ok := e.Expression().(*parser.PrimaryExpressionContext); ok {
+			if _, ok := primary.Primary().(*parser.ThisPrimaryContext); ok {
+				break
+			}
+		}
+		qualifier = e.Expression().GetText()
+		if strings.ContainsAny(qualifier, "()[]") {
+			return nil
+		}
+	default:
+		return nil
+	}
+	// look up the name in each enclosing scope, innermost first
+	var candidates []string
+	parts := strings.Split(scope, ".")
+	for i := len(parts); i >= 0; i-- {
+		prefix := strings.Join(parts[:i], ".")
+		key := qualifier
+		if prefix != "" && key != "" {
+			key = prefix + "." + key
+		} else if prefix != "" {
+			key = prefix
+		}
+		if key == "" {
+			continue
+		}
+		candidates = append(candidates, strings.ToLower(key+"."+name))
+	}
+	return candidates
+}
+
+// enclosingScope returns the name of the class, including enclosing
+// classes, or trigger containing node, e.g. Outer.Inner.
+func enclosingScope(node antlr.Tree) string {
+	var names []string
+	for parent := node.GetParent(); parent != nil; parent = parent.GetParent() {
+		switch c := parent.(type) {
+		case *parser.ClassDeclarationContext:
+			names = append([]string{c.Id().GetText()}, names...)
+		case *parser.TriggerUnitContext:
+			names = append([]string{c.Id(0).GetText()}, names...)
+		}
+	}
+	return strings.Join(names, ".")
+}
+
+// projectRule is implemented by rules that follow calls into methods
+// summarized by a Project.
+type projectRule interface {
+	checkProject(tree antlr.ParseTree, tokens *antlr.CommonTokenStream, project *Project, filename string) []Diagnostic
+}
+
+// loopCalls reports calls inside loops to methods that perform an operation
+// of the given kind.  If project is nil, only methods in tree are followed.
+func loopCalls(rule string, kind int, tree antlr.ParseTree, project *Project, filename string) []Diagnostic {
+	if project == nil {
+		project = NewProject()
+		project.addTree(filename, tree)
+	}
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		if _, what := operation(ctx); what != "" {
+			return
+		}
+		candidates := callTargets(ctx, enclosingScope(ctx))
+		if candidates == nil {
+			return
+		}
+		loop := enclosingLoop(ctx)
+		if loop == nil {
+			return
+		}
+		e := project.effect(candidates, kind, make(map[string]bool))
+		if e == nil {
+			return
+		}
+		location := fmt.Sprintf("line %d", e.line)
+		if e.file != filename {
+			location = fmt.Sprintf("%s:%d", e.file, e.line)
+		}
+		diagnostics = append(diagnostics, diagnostic(rule, ctx, "call to %s performs %s on %s inside loop starting on line %d", calledName(ctx), e.what, location, loop.GetStart().GetLine()))
+	})
+	return diagnostics
+}
+
+func calledName(ctx antlr.ParserRuleContext) string {
+	switch e := ctx.(type) {
+	case *parser.MethodCallExpressionContext:
+		return e.MethodCall().Id().GetText()
+	case *parser.DotExpressionContext:
+		return e.Expression().GetText() + "." + e.DotMethodCall().AnyId().GetText()
+	}
+	return ""
+}
@@ -0,0 +1,156 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+	"github.com/octoberswimmer/apexfmt/report"
+)
+
+// Diagnostic is a single finding.  Line and Column are 1-based.
+type Diagnostic struct {
+	Rule    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", d.Line, d.Column, d.Message, d.Rule)
+}
+
+// Rule checks a parsed compilation unit.
+type Rule interface {
+	// Name is the identifier used to report and disable the rule.
+	Name() string
+	Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic
+}
+
+// DefaultRules returns the rules run by `apexfmt lint`.
+func DefaultRules() []Rule {
+	return []Rule{
+		&soqlInLoop{},
+		&dmlInLoop{},
+		&emptyCatch{},
+		&hardcodedId{},
+		&debugStatement{},
+		&missingSharing{},
+	}
+}
+
+// OptionalRules returns rules that `apexfmt lint` only runs when enabled.
+func OptionalRules() []Rule {
+	return []Rule{
+		&missingApexDoc{},
+		&unused{},
+		&triggerLogic{},
+	}
+}
+
+type Linter struct {
+	filename    string
+	reader      io.Reader
+	rules       []Rule
+	project     *Project
+	diagnostics []Diagnostic
+}
+
+func NewLinter(filename string, reader io.Reader, rules []Rule) *Linter {
+	if filename != "" {
+		return &Linter{
+			filename: filename,
+			rules:    rules,
+		}
+	}
+	return &Linter{
+		reader: reader,
+		rules:  rules,
+	}
+}
+
+func (l *Linter) SourceName() string {
+	if l.filename != "" {
+		return l.filename
+	}
+	return "<stdin>"
+}
+
+// SetProject sets the project whose methods are followed by rules that
+// check calls made inside loops.  Without a project, only methods declared
+// in the same file are followed.
+func (l *Linter) SetProject(project *Project) {
+	l.project = project
+}
+
+// Lint parses the source and runs every rule against it.  Syntax errors are
+// returned as diagnostics; rules are not run on sources that fail to parse.
+func (l *Linter) Lint() ([]Diagnostic, error) {
+	tree, stream, err := formatter.Parse(l.filename, l.reader)
+	var syntaxErrors *formatter.SyntaxErrors
+	if errors.As(err, &syntaxErrors) {
+		l.diagnostics = syntaxDiagnostics(syntaxErrors)
+		return l.diagnostics, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.diagnostics = []Diagnostic{}
+	for _, r := range l.rules {
+		if pr, ok := r.(projectRule); ok && l.project != nil {
+			l.diagnostics = append(l.diagnostics, pr.checkProject(tree, stream, l.project, l.filename)...)
+			continue
+		}
+		l.diagnostics = append(l.diagnostics, r.Check(tree, stream)...)
+	}
+	sort.SliceStable(l.diagnostics, func(i, j int) bool {
+		if l.diagnostics[i].Line != l.diagnostics[j].Line {
+			return l.diagnostics[i].Line < l.diagnostics[j].Line
+		}
+		return l.diagnostics[i].Column < l.diagnostics[j].Column
+	})
+	return l.diagnostics, nil
+}
+
+// syntaxDiagnostics converts syntax errors to diagnostics.
+func syntaxDiagnostics(e *formatter.SyntaxErrors) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, s := range e.Errors {
+		diagnostics = append(diagnostics, Diagnostic{
+			Rule:    report.SyntaxErrorRule,
+			Line:    s.Line,
+			Column:  s.Column + 1,
+			Message: s.Message,
+		})
+	}
+	return diagnostics
+}
+
+// inspector calls fn for every rule node in a parse tree, in depth-first
+// order.
+type inspector struct {
+	parser.BaseApexParserListener
+	fn func(antlr.ParserRuleContext)
+}
+
+func (i *inspector) EnterEveryRule(ctx antlr.ParserRuleContext) {
+	i.fn(ctx)
+}
+
+func inspect(tree antlr.ParseTree, fn func(antlr.ParserRuleContext)) {
+	antlr.ParseTreeWalkerDefault.Walk(&inspector{fn: fn}, tree)
+}
+
+func diagnostic(rule string, ctx antlr.ParserRuleContext, format string, a ...interface{}) Diagnostic {
+	start := ctx.GetStart()
+	return Diagnostic{
+		Rule:    rule,
+		Line:    start.GetLine(),
+		Column:  start.GetColumn() + 1,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
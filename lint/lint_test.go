@@ -0,0 +1,322 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	tests :=
+		[]struct {
+			input  string
+			output []string
+		}{
+			{
+				`public with sharing class Foo {
+	public void run() {
+		for (Account a : [SELECT Id FROM Account]) {
+			Contact c = [SELECT Id FROM Contact WHERE AccountId = :a.Id];
+		}
+	}
+}`,
+				[]string{`4:16: SOQL query inside loop starting on line 3 (soql-in-loop)`}},
+			{
+				`public with sharing class Foo {
+	public void run(List<Account> accounts) {
+		for (Integer i = 0; i < 10; i++) {
+			insert accounts;
+		}
+		while (true) {
+			delete accounts;
+		}
+	}
+}`,
+				[]string{
+					`4:4: DML insert inside loop starting on line 3 (dml-in-loop)`,
+					`7:4: DML delete inside loop starting on line 6 (dml-in-loop)`,
+				}},
+			{
+				`public with sharing class Foo {
+	public void run(List<Account> accounts) {
+		for (Account a : accounts) {
+			List<SObject> rows = Database.query('SELECT Id FROM Contact');
+			Database.update(a);
+			load(a.Id);
+			this.save(a);
+			Foo.format(a);
+		}
+	}
+	private void load(Id accountId) {
+		fetch();
+	}
+	private void fetch() {
+		Contact c = [SELECT Id FROM Contact LIMIT 1];
+	}
+	private void save(Account a) {
+		upsert a;
+	}
+	private static String format(Account a) {
+		return a.Name;
+	}
+}`,
+				[]string{
+					`4:25: Database.query call inside loop starting on line 3 (soql-in-loop)`,
+					`5:4: Database.update call inside loop starting on line 3 (dml-in-loop)`,
+					`6:4: call to load performs SOQL query on line 15 inside loop starting on line 3 (soql-in-loop)`,
+					`7:4: call to this.save performs DML upsert on line 18 inside loop starting on line 3 (dml-in-loop)`,
+				}},
+			{
+				`public with sharing class Foo {
+	public void run() {
+		try {
+			run();
+		} catch (Exception e) {
+		}
+		try {
+			run();
+		} catch (Exception e) {
+			// safe to ignore
+		}
+	}
+}`,
+				[]string{`5:5: empty catch block ignores Exception (empty-catch)`}},
+			{
+				`public with sharing class Foo {
+	Id ownerId = '005000000000001AAA';
+	Id badChecksum = '005000000000001AAB';
+	String name = 'not an id';
+}`,
+				[]string{`2:15: hard-coded record id '005000000000001AAA' (hardcoded-id)`}},
+			{
+				`public with sharing class Foo {
+	public void run() {
+		System.debug('here');
+		Logger.debug('here');
+	}
+}`,
+				[]string{`3:3: System.debug call left in code (debug-statement)`}},
+			{
+				`public class Foo {
+	public class Bar {}
+}`,
+				[]string{`1:1: class Foo does not declare with sharing, without sharing, or inherited sharing (missing-sharing)`}},
+			{
+				`public with sharing class Foo {
+	Integer i = 1
+}`,
+				[]string{`3:1: missing ';' at '}' (syntax-error)`}},
+		}
+	for _, tt := range tests {
+		l := NewLinter("", strings.NewReader(tt.input), DefaultRules())
+		diagnostics, err := l.Lint()
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+		out := []string{}
+		for _, d := range diagnostics {
+			out = append(out, d.String())
+		}
+		if strings.Join(out, "\n") != strings.Join(tt.output, "\n") {
+			t.Errorf("unexpected diagnostics.  expected:\n%s\ngot:\n%s\n", strings.Join(tt.output, "\n"), strings.Join(out, "\n"))
+		}
+	}
+}
+
+func TestMissingApexDoc(t *testing.T) {
+	input := `/**
+ * Documented.
+ */
+public with sharing class Foo {
+	/** Documented. */
+	public void documented() {}
+	// not ApexDoc
+	global void run(String name) {}
+	private void helper() {}
+	void defaultAccess() {}
+	@AuraEnabled
+	public static String label() { return null; }
+	public class Inner {}
+	public interface Shape {
+		Decimal area();
+	}
+	private interface Hidden {
+		void hide();
+	}
+}`
+	expected := []string{
+		`8:2: global method run has no ApexDoc comment (missing-apexdoc)`,
+		`11:2: public method label has no ApexDoc comment (missing-apexdoc)`,
+		`13:2: public class Inner has no ApexDoc comment (missing-apexdoc)`,
+		`14:2: public interface Shape has no ApexDoc comment (missing-apexdoc)`,
+		`15:3: public method area has no ApexDoc comment (missing-apexdoc)`,
+	}
+	l := NewLinter("", strings.NewReader(input), []Rule{&missingApexDoc{}})
+	diagnostics, err := l.Lint()
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	out := []string{}
+	for _, d := range diagnostics {
+		out = append(out, d.String())
+	}
+	if strings.Join(out, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diagnostics.  expected:\n%s\ngot:\n%s\n", strings.Join(expected, "\n"), strings.Join(out, "\n"))
+	}
+}
+
+func TestProject(t *testing.T) {
+	dir := t.TempDir()
+	service := filepath.Join(dir, "AccountService.cls")
+	caller := filepath.Join(dir, "Foo.cls")
+	os.WriteFile(service, []byte(`public with sharing class AccountService {
+	public static void archive(List<Account> accounts) {
+		helper(accounts);
+	}
+	private static void helper(List<Account> accounts) {
+		delete accounts;
+	}
+}`), 0644)
+	os.WriteFile(caller, []byte(`public with sharing class Foo {
+	public void run(List<List<Account>> batches) {
+		for (List<Account> batch : batches) {
+			AccountService.archive(batch);
+		}
+	}
+}`), 0644)
+	project := NewProject()
+	for _, f := range []string{service, caller} {
+		if err := project.Add(f, nil); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+	}
+	l := NewLinter(caller, nil, DefaultRules())
+	l.SetProject(project)
+	diagnostics, err := l.Lint()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	expected := fmt.Sprintf("4:4: call to AccountService.archive performs DML delete on %s:6 inside loop starting on line 3 (dml-in-loop)", service)
+	if len(diagnostics) != 1 || diagnostics[0].String() != expected {
+		t.Errorf("unexpected diagnostics.  expected:\n%s\ngot:\n%v\n", expected, diagnostics)
+	}
+}
+
+func TestMissingApexDocInTrigger(t *testing.T) {
+	input := `trigger T on Account (before insert) {
+	public interface Greeter {
+		String greet();
+	}
+}`
+	expected := []string{
+		`3:3: public method greet has no ApexDoc comment (missing-apexdoc)`,
+	}
+	l := NewLinter("", strings.NewReader(input), []Rule{&missingApexDoc{}})
+	diagnostics, err := l.Lint()
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	out := []string{}
+	for _, d := range diagnostics {
+		out = append(out, d.String())
+	}
+	if strings.Join(out, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diagnostics.  expected:\n%s\ngot:\n%s\n", strings.Join(expected, "\n"), strings.Join(out, "\n"))
+	}
+}
+
+func TestUnused(t *testing.T) {
+	input := `public with sharing class Foo {
+	private Integer count;
+	public void run(String name) {
+		Integer total = 0;
+		helper(name);
+	}
+	private void helper(String value) {}
+}`
+	expected := []string{
+		`2:18: private field count is never used (unused)`,
+		`4:11: local variable total is never read (unused)`,
+		`7:22: parameter value is never read (unused)`,
+	}
+	l := NewLinter("", strings.NewReader(input), []Rule{&unused{}})
+	diagnostics, err := l.Lint()
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	out := []string{}
+	for _, d := range diagnostics {
+		out = append(out, d.String())
+	}
+	if strings.Join(out, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diagnostics.  expected:\n%s\ngot:\n%s\n", strings.Join(expected, "\n"), strings.Join(out, "\n"))
+	}
+}
+
+func TestTriggerLogic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			`trigger AccountTrigger on Account (before insert) {
+	AccountHandler.run();
+}`,
+			[]string{},
+		},
+		{
+			`trigger AccountTrigger on Account (before insert) {
+	new AccountHandler().run();
+}`,
+			[]string{},
+		},
+		{
+			`trigger AccountTrigger on Account (before insert) {
+	AccountHandler.run();
+	for (Account a : Trigger.new) {
+		a.Name = 'x';
+	}
+}`,
+			[]string{`3:2: trigger AccountTrigger contains logic beyond a single handler call (trigger-logic)`},
+		},
+		{
+			`trigger AccountTrigger on Account (before insert) {
+	if (Trigger.isBefore) {
+		AccountHandler.run();
+	}
+}`,
+			[]string{`2:2: trigger AccountTrigger contains logic beyond a single handler call (trigger-logic)`},
+		},
+		{
+			`trigger AccountTrigger on Account (before insert) {
+	Integer count = 0;
+}`,
+			[]string{`2:2: trigger AccountTrigger contains logic beyond a single handler call (trigger-logic)`},
+		},
+		{
+			`public class Foo {
+	public void run() {
+		a();
+		b();
+	}
+}`,
+			[]string{},
+		},
+	}
+	for _, tt := range tests {
+		l := NewLinter("", strings.NewReader(tt.input), []Rule{&triggerLogic{}})
+		diagnostics, err := l.Lint()
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+		out := []string{}
+		for _, d := range diagnostics {
+			out = append(out, d.String())
+		}
+		if strings.Join(out, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("unexpected diagnostics.  expected:\n%s\ngot:\n%s\n", strings.Join(tt.expected, "\n"), strings.Join(out, "\n"))
+		}
+	}
+}
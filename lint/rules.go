@@ -0,0 +1,216 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+type soqlInLoop struct{}
+
+func (r *soqlInLoop) Name() string {
+	return "soql-in-loop"
+}
+
+func (r *soqlInLoop) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	return r.checkProject(tree, tokens, nil, "")
+}
+
+func (r *soqlInLoop) checkProject(tree antlr.ParseTree, tokens *antlr.CommonTokenStream, project *Project, filename string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		kind, what := operation(ctx)
+		if what == "" || kind != soqlEffect {
+			return
+		}
+		if loop := enclosingLoop(ctx); loop != nil {
+			diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "%s inside loop starting on line %d", what, loop.GetStart().GetLine()))
+		}
+	})
+	return append(diagnostics, loopCalls(r.Name(), soqlEffect, tree, project, filename)...)
+}
+
+type dmlInLoop struct{}
+
+func (r *dmlInLoop) Name() string {
+	return "dml-in-loop"
+}
+
+func (r *dmlInLoop) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	return r.checkProject(tree, tokens, nil, "")
+}
+
+func (r *dmlInLoop) checkProject(tree antlr.ParseTree, tokens *antlr.CommonTokenStream, project *Project, filename string) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		kind, what := operation(ctx)
+		if what == "" || kind != dmlEffect {
+			return
+		}
+		if loop := enclosingLoop(ctx); loop != nil {
+			diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "%s inside loop starting on line %d", what, loop.GetStart().GetLine()))
+		}
+	})
+	return append(diagnostics, loopCalls(r.Name(), dmlEffect, tree, project, filename)...)
+}
+
+func isDMLStatement(ctx antlr.ParserRuleContext) bool {
+	switch ctx.(type) {
+	case *parser.InsertStatementContext,
+		*parser.UpdateStatementContext,
+		*parser.UpsertStatementContext,
+		*parser.DeleteStatementContext,
+		*parser.UndeleteStatementContext,
+		*parser.MergeStatementContext:
+		return true
+	}
+	return false
+}
+
+// enclosingLoop returns the nearest loop that executes node on every
+// iteration, or nil.  The initializer of a for loop and the collection of an
+// enhanced for loop run only once, so they are not considered inside the
+// loop.
+func enclosingLoop(node antlr.Tree) antlr.ParserRuleContext {
+	header := false
+	child := node
+	for parent := node.GetParent(); parent != nil; child, parent = parent, parent.GetParent() {
+		switch loop := parent.(type) {
+		case *parser.ForControlContext:
+			switch child.(type) {
+			case *parser.EnhancedForControlContext, *parser.ForInitContext:
+				header = true
+			}
+		case *parser.ForStatementContext:
+			if _, ok := child.(*parser.StatementContext); ok || !header {
+				return loop
+			}
+			header = false
+		case *parser.WhileStatementContext:
+			return loop
+		case *parser.DoWhileStatementContext:
+			return loop
+		}
+	}
+	return nil
+}
+
+type emptyCatch struct{}
+
+func (r *emptyCatch) Name() string {
+	return "empty-catch"
+}
+
+// Catch blocks containing only a comment are assumed to be intentionally
+// empty.
+func (r *emptyCatch) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		c, ok := ctx.(*parser.CatchClauseContext)
+		if !ok {
+			return
+		}
+		block := c.Block()
+		if len(block.AllStatement()) > 0 {
+			return
+		}
+		if comments := tokens.GetHiddenTokensToLeft(block.GetStop().GetTokenIndex(), formatter.COMMENTS_CHANNEL); len(comments) > 0 {
+			return
+		}
+		diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "empty catch block ignores %s", c.QualifiedName().GetText()))
+	})
+	return diagnostics
+}
+
+type hardcodedId struct{}
+
+func (r *hardcodedId) Name() string {
+	return "hardcoded-id"
+}
+
+var idPattern = regexp.MustCompile(`^[a-zA-Z0-9]{5}0[a-zA-Z0-9]{9}([a-zA-Z0-9]{3})?$`)
+
+func (r *hardcodedId) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		l, ok := ctx.(*parser.LiteralContext)
+		if !ok || l.StringLiteral() == nil {
+			return
+		}
+		text := strings.Trim(l.GetText(), "'")
+		if isSalesforceId(text) {
+			diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "hard-coded record id '%s'", text))
+		}
+	})
+	return diagnostics
+}
+
+// isSalesforceId reports whether s looks like a 15-character record id or an
+// 18-character record id with a valid checksum suffix.
+func isSalesforceId(s string) bool {
+	if !idPattern.MatchString(s) {
+		return false
+	}
+	if len(s) == 15 {
+		return true
+	}
+	const suffixChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"
+	for i := 0; i < 3; i++ {
+		flags := 0
+		for j, c := range s[i*5 : i*5+5] {
+			if c >= 'A' && c <= 'Z' {
+				flags |= 1 << j
+			}
+		}
+		if s[15+i] != suffixChars[flags] {
+			return false
+		}
+	}
+	return true
+}
+
+type debugStatement struct{}
+
+func (r *debugStatement) Name() string {
+	return "debug-statement"
+}
+
+func (r *debugStatement) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		d, ok := ctx.(*parser.DotExpressionContext)
+		if !ok || d.DotMethodCall() == nil {
+			return
+		}
+		if strings.EqualFold(d.DotMethodCall().AnyId().GetText(), "debug") && strings.EqualFold(d.Expression().GetText(), "System") {
+			diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "System.debug call left in code"))
+		}
+	})
+	return diagnostics
+}
+
+type missingSharing struct{}
+
+func (r *missingSharing) Name() string {
+	return "missing-sharing"
+}
+
+func (r *missingSharing) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		t, ok := ctx.(*parser.TypeDeclarationContext)
+		if !ok || t.ClassDeclaration() == nil {
+			return
+		}
+		for _, m := range t.AllModifier() {
+			if m.SHARING() != nil {
+				return
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic(r.Name(), ctx, "class %s does not declare with sharing, without sharing, or inherited sharing", t.ClassDeclaration().Id().GetText()))
+	})
+	return diagnostics
+}
@@ -0,0 +1,47 @@
+package lint
+
+import (
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// triggerLogic enforces the handler pattern: a trigger body should consist
+// of a single call that delegates to a handler class.
+type triggerLogic struct{}
+
+func (r *triggerLogic) Name() string {
+	return "trigger-logic"
+}
+
+func (r *triggerLogic) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	inspect(tree, func(ctx antlr.ParserRuleContext) {
+		trigger, ok := ctx.(*parser.TriggerUnitContext)
+		if !ok {
+			return
+		}
+		for i, stmt := range trigger.TriggerBlock().AllTriggerStatement() {
+			if i == 0 && isHandlerCall(stmt) {
+				continue
+			}
+			diagnostics = append(diagnostics, diagnostic(r.Name(), stmt, "trigger %s contains logic beyond a single handler call", trigger.Id(0).GetText()))
+			return
+		}
+	})
+	return diagnostics
+}
+
+// isHandlerCall reports whether stmt is a method call statement, such as
+// `AccountHandler.run();` or `new AccountHandler().run();`.
+func isHandlerCall(stmt parser.ITriggerStatementContext) bool {
+	if stmt.Statement() == nil || stmt.Statement().ExpressionStatement() == nil {
+		return false
+	}
+	switch e := stmt.Statement().ExpressionStatement().Expression().(type) {
+	case *parser.MethodCallExpressionContext:
+		return true
+	case *parser.DotExpressionContext:
+		return e.DotMethodCall() != nil
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package lint
+
+import (
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+)
+
+type unused struct{}
+
+func (r *unused) Name() string {
+	return "unused"
+}
+
+func (r *unused) Check(tree antlr.ParseTree, tokens *antlr.CommonTokenStream) []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, u := range formatter.FindUnused(tree) {
+		verb := "used"
+		if u.Kind == formatter.UnusedLocal || u.Kind == formatter.UnusedParameter {
+			verb = "read"
+		}
+		diagnostics = append(diagnostics, diagnostic(r.Name(), u.Node, "%s %s is never %s", u.Kind, u.Name, verb))
+	}
+	return diagnostics
+}
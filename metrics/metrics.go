@@ -0,0 +1,261 @@
+// Package metrics computes code metrics, such as cyclomatic complexity and
+// nesting depth, for Apex methods and types.
+package metrics
+
+import (
+	"io"
+
+	"github.com/octoberswimmer/apexfmt/ast"
+)
+
+// Metrics are the measurements of a method or type.
+//
+// Complexity is the cyclomatic complexity: one plus the number of if, for,
+// while and do statements, catch clauses, when clauses other than when else,
+// && and || operators, and ternary expressions.  Nesting is the maximum
+// depth of nested control statements.  Statements does not count blocks.
+// SOQL counts inline SOQL and SOSL queries and Database.query calls; DML
+// counts DML statements and Database DML method calls.
+type Metrics struct {
+	Complexity int `json:"complexity"`
+	Nesting    int `json:"nesting"`
+	Statements int `json:"statements"`
+	Parameters int `json:"parameters"`
+	SOQL       int `json:"soql"`
+	DML        int `json:"dml"`
+}
+
+// Method holds the metrics of a method, constructor, property accessor or
+// initializer.
+type Method struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	Metrics
+}
+
+// Type holds the metrics of a class or trigger.  Its Complexity, Statements,
+// SOQL and DML are the totals of its methods, and its Nesting and Parameters
+// are their maximums.  A trigger's own statements are measured as a method
+// named (body).
+type Type struct {
+	Kind    string    `json:"kind"`
+	Name    string    `json:"name"`
+	Line    int       `json:"line"`
+	Methods []*Method `json:"methods"`
+	Metrics
+}
+
+// Parse parses Apex source and computes the metrics of its classes and
+// triggers, including inner classes, which are named Outer.Inner.
+func Parse(filename string, reader io.Reader) ([]*Type, error) {
+	f, err := ast.Parse(filename, reader)
+	if err != nil {
+		return nil, err
+	}
+	return Compute(f), nil
+}
+
+// Compute computes the metrics of the classes and triggers in f.
+func Compute(f *ast.File) []*Type {
+	var types []*Type
+	switch d := f.Decl.(type) {
+	case *ast.ClassDecl:
+		types = class(types, "", d)
+	case *ast.TriggerDecl:
+		t := &Type{Kind: "trigger", Name: d.Name, Line: d.Pos().Line}
+		body := &Method{Name: "(body)", Line: d.Pos().Line, Metrics: measure(&ast.Block{Span: d.Span, Stmts: d.Body})}
+		t.Methods = append(t.Methods, body)
+		t.add(body.Metrics)
+		for _, s := range d.Body {
+			if decl, ok := s.(*ast.DeclStmt); ok {
+				if m := method(decl.Decl); m != nil {
+					t.Methods = append(t.Methods, m)
+					t.add(m.Metrics)
+				}
+			}
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+func class(types []*Type, outer string, d *ast.ClassDecl) []*Type {
+	t := &Type{Kind: "class", Name: outer + d.Name, Line: d.Pos().Line}
+	types = append(types, t)
+	for _, member := range d.Members {
+		if inner, ok := member.(*ast.ClassDecl); ok {
+			types = class(types, t.Name+".", inner)
+			continue
+		}
+		for _, m := range methods(member) {
+			t.Methods = append(t.Methods, m)
+			t.add(m.Metrics)
+		}
+	}
+	return types
+}
+
+func (t *Type) add(m Metrics) {
+	t.Complexity += m.Complexity
+	t.Statements += m.Statements
+	t.SOQL += m.SOQL
+	t.DML += m.DML
+	t.Nesting = max(t.Nesting, m.Nesting)
+	t.Parameters = max(t.Parameters, m.Parameters)
+}
+
+func methods(d ast.Decl) []*Method {
+	p, ok := d.(*ast.PropertyDecl)
+	if !ok {
+		if m := method(d); m != nil {
+			return []*Method{m}
+		}
+		return nil
+	}
+	var accessors []*Method
+	for _, a := range []struct {
+		name     string
+		accessor *ast.Accessor
+	}{{"get", p.Getter}, {"set", p.Setter}} {
+		if a.accessor != nil && a.accessor.Body != nil {
+			accessors = append(accessors, &Method{
+				Name:    p.Name + "." + a.name,
+				Line:    a.accessor.Pos().Line,
+				Metrics: measure(a.accessor.Body),
+			})
+		}
+	}
+	return accessors
+}
+
+func method(d ast.Decl) *Method {
+	switch d := d.(type) {
+	case *ast.MethodDecl:
+		if d.Body == nil {
+			return nil
+		}
+		m := &Method{Name: d.Name, Line: d.Pos().Line, Metrics: measure(d.Body)}
+		m.Parameters = len(d.Params)
+		return m
+	case *ast.ConstructorDecl:
+		m := &Method{Name: d.Name, Line: d.Pos().Line, Metrics: measure(d.Body)}
+		m.Parameters = len(d.Params)
+		return m
+	case *ast.InitializerDecl:
+		name := "(initializer)"
+		if d.Static {
+			name = "(static initializer)"
+		}
+		return &Method{Name: name, Line: d.Pos().Line, Metrics: measure(d.Body)}
+	}
+	return nil
+}
+
+// measure computes the metrics of the body of a method.
+func measure(body ast.Node) Metrics {
+	m := Metrics{Complexity: 1}
+	var walk func(n ast.Node, depth int)
+	walk = func(n ast.Node, depth int) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n.(type) {
+			case *ast.DeclStmt:
+				// methods declared in triggers are measured separately
+				return false
+			case *ast.Block, *ast.ExprListStmt:
+			case ast.Stmt:
+				m.Statements++
+			}
+			switch n := n.(type) {
+			case *ast.IfStmt:
+				m.Complexity++
+				m.Nesting = max(m.Nesting, depth+1)
+				walk(n.Cond, depth)
+				walk(n.Then, depth+1)
+				if n.Else == nil {
+					return false
+				}
+				if elseIf, ok := n.Else.(*ast.IfStmt); ok {
+					// else if continues the chain at the same depth
+					walk(elseIf, depth)
+				} else {
+					walk(n.Else, depth+1)
+				}
+				return false
+			case *ast.ForStmt:
+				m.Complexity++
+				m.Nesting = max(m.Nesting, depth+1)
+				// the loop's initialization is not a separate statement
+				if init, ok := n.Init.(*ast.LocalVarStmt); ok {
+					for _, v := range init.Vars {
+						walk(v, depth)
+					}
+				} else if n.Init != nil {
+					walk(n.Init, depth)
+				}
+				if n.Cond != nil {
+					walk(n.Cond, depth)
+				}
+				for _, u := range n.Update {
+					walk(u, depth)
+				}
+				walk(n.Body, depth+1)
+				return false
+			case *ast.ForEachStmt, *ast.WhileStmt, *ast.DoWhileStmt:
+				m.Complexity++
+				nested(n, depth, walk, &m)
+				return false
+			case *ast.SwitchStmt:
+				for _, w := range n.Whens {
+					if !w.Else {
+						m.Complexity++
+					}
+				}
+				nested(n, depth, walk, &m)
+				return false
+			case *ast.TryStmt:
+				m.Complexity += len(n.Catches)
+				nested(n, depth, walk, &m)
+				return false
+			case *ast.RunAsStmt:
+				nested(n, depth, walk, &m)
+				return false
+			case *ast.BinaryExpr:
+				if n.Op == "&&" || n.Op == "||" {
+					m.Complexity++
+				}
+			case *ast.CondExpr:
+				m.Complexity++
+			case *ast.SOQLQuery, *ast.SOSLQuery:
+				m.SOQL++
+			case *ast.DMLStmt:
+				m.DML++
+			case *ast.CallExpr:
+				if recv, ok := n.Recv.(*ast.Ident); ok {
+					switch ast.Operation(recv.Name, n.Name) {
+					case ast.QueryOperation:
+						m.SOQL++
+					case ast.DMLOperation:
+						m.DML++
+					}
+				}
+			}
+			return true
+		})
+	}
+	walk(body, 0)
+	return m
+}
+
+// nested walks the children of a control statement one level deeper.
+func nested(n ast.Node, depth int, walk func(ast.Node, int), m *Metrics) {
+	m.Nesting = max(m.Nesting, depth+1)
+	first := true
+	ast.Inspect(n, func(child ast.Node) bool {
+		if first {
+			first = false
+			return true
+		}
+		walk(child, depth+1)
+		return false
+	})
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `public with sharing class Foo {
+	public Integer count { get { return count == null ? 0 : count; } set; }
+	public void run(List<Account> accounts, Boolean force) {
+		for (Integer i = 0; i < 10; i++) {
+			if (force && i > 2 || i == 5) {
+				insert accounts;
+			} else if (i == 3) {
+				Database.update(accounts);
+			} else {
+				List<Contact> c = [SELECT Id FROM Contact];
+			}
+		}
+		try {
+			switch on accounts.size() {
+				when 1, 2 { System.debug(1); }
+				when else { }
+			}
+		} catch (DmlException e) {
+		} catch (Exception e) {
+		}
+	}
+	public class Inner {
+		Inner() { x = 1; }
+	}
+}`
+	types, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(types) != 2 || types[0].Name != "Foo" || types[1].Name != "Foo.Inner" {
+		t.Fatalf("unexpected types: %#v", types)
+	}
+	foo := types[0]
+	expected := Metrics{Complexity: 11, Nesting: 2, Statements: 10, Parameters: 2, SOQL: 1, DML: 2}
+	if foo.Metrics != expected {
+		t.Errorf("unexpected class metrics.  expected %+v, got %+v", expected, foo.Metrics)
+	}
+	if len(foo.Methods) != 2 || foo.Methods[0].Name != "count.get" || foo.Methods[1].Name != "run" {
+		t.Fatalf("unexpected methods: %#v", foo.Methods)
+	}
+	expected = Metrics{Complexity: 9, Nesting: 2, Statements: 9, Parameters: 2, SOQL: 1, DML: 2}
+	if run := foo.Methods[1]; run.Metrics != expected || run.Line != 3 {
+		t.Errorf("unexpected method metrics.  expected %+v, got %+v", expected, run.Metrics)
+	}
+
+	files := []File{{File: "Foo.cls", Types: types}}
+	var out bytes.Buffer
+	if err := Write(&out, "csv", files); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	csv := `file,kind,name,line,complexity,nesting,statements,parameters,soql,dml
+Foo.cls,class,Foo,1,11,2,10,2,1,2
+Foo.cls,method,Foo.count.get,2,2,0,1,0,0,0
+Foo.cls,method,Foo.run,3,9,2,9,2,1,2
+Foo.cls,class,Foo.Inner,22,1,0,1,0,0,0
+Foo.cls,method,Foo.Inner.Inner,23,1,0,1,0,0,0
+`
+	if out.String() != csv {
+		t.Errorf("unexpected csv.  expected:\n%s\ngot:\n%s", csv, out.String())
+	}
+
+	violations := Check(files, Metrics{Complexity: 5, Nesting: 2})
+	if len(violations) != 1 || violations[0].String() != "Foo.cls:3: Foo.run complexity is 9, more than 5" {
+		t.Errorf("unexpected violations: %v", violations)
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	src := `trigger AccountTrigger on Account (before insert) {
+	if (Trigger.isBefore) {
+		helper(Trigger.new);
+	}
+	private void helper(List<Account> accounts) {
+		for (Account a : accounts) {
+			while (a.Name == null) {
+				a.Name = [SELECT Name FROM Account LIMIT 1].Name;
+			}
+		}
+	}
+}`
+	types, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	trigger := types[0]
+	if trigger.Kind != "trigger" || len(trigger.Methods) != 2 {
+		t.Fatalf("unexpected trigger: %#v", trigger)
+	}
+	if body := trigger.Methods[0]; body.Name != "(body)" || body.Metrics != (Metrics{Complexity: 2, Nesting: 1, Statements: 2}) {
+		t.Errorf("unexpected body metrics: %+v", body.Metrics)
+	}
+	if helper := trigger.Methods[1]; helper.Metrics != (Metrics{Complexity: 3, Nesting: 2, Statements: 3, Parameters: 1, SOQL: 1}) {
+		t.Errorf("unexpected helper metrics: %+v", helper.Metrics)
+	}
+}
+
+func TestDatabaseOperations(t *testing.T) {
+	src := `public class Foo {
+	public void run(String q, Map<String, Object> binds, List<Database.LeadConvert> leads) {
+		Database.getQueryLocator(q);
+		database.countQueryWithBinds(q, binds, AccessLevel.USER_MODE);
+		Search.query(q);
+		Database.convertLead(leads);
+		Database.emptyRecycleBin(leads);
+		Database.rollback(null);
+	}
+}`
+	types, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m := types[0].Methods[0].Metrics; m.SOQL != 3 || m.DML != 2 {
+		t.Errorf("unexpected operation counts: %+v", m)
+	}
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{"table", "csv", "json"}
+
+// File holds the metrics of the types in a file.
+type File struct {
+	File  string  `json:"file"`
+	Types []*Type `json:"types"`
+}
+
+// Valid reports whether format is one of Formats.
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+var columns = []string{"file", "kind", "name", "line", "complexity", "nesting", "statements", "parameters", "soql", "dml"}
+
+// rows returns a row per type, followed by a row per method of the type.
+// Methods are named Type.method.
+func rows(files []File) [][]string {
+	var rows [][]string
+	row := func(file, kind, name string, line int, m Metrics) {
+		rows = append(rows, []string{file, kind, name, strconv.Itoa(line),
+			strconv.Itoa(m.Complexity), strconv.Itoa(m.Nesting), strconv.Itoa(m.Statements),
+			strconv.Itoa(m.Parameters), strconv.Itoa(m.SOQL), strconv.Itoa(m.DML)})
+	}
+	for _, f := range files {
+		for _, t := range f.Types {
+			row(f.File, t.Kind, t.Name, t.Line, t.Metrics)
+			for _, m := range t.Methods {
+				row(f.File, "method", t.Name+"."+m.Name, m.Line, m.Metrics)
+			}
+		}
+	}
+	return rows
+}
+
+// Write writes the metrics of files to w as a table, CSV or JSON.
+func Write(w io.Writer, format string, files []File) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+		for _, r := range append([][]string{columns}, rows(files)...) {
+			for _, cell := range r {
+				fmt.Fprintf(tw, "%s\t", cell)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(columns)
+		cw.WriteAll(rows(files))
+		return cw.Error()
+	case "json":
+		if files == nil {
+			files = []File{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(files)
+	}
+	return fmt.Errorf("Unsupported format: %s", format)
+}
+
+// Violation is a method whose metric exceeds its limit.
+type Violation struct {
+	File   string
+	Line   int
+	Name   string
+	Metric string
+	Value  int
+	Limit  int
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s:%d: %s %s is %d, more than %d", v.File, v.Line, v.Name, v.Metric, v.Value, v.Limit)
+}
+
+// Check returns the methods in files whose metrics exceed limits.  A limit of zero is not checked.
+func Check(files []File, limits Metrics) []Violation {
+	var violations []Violation
+	check := func(file, name string, line int, m Metrics) {
+		for _, c := range []struct {
+			metric       string
+			value, limit int
+		}{
+			{"complexity", m.Complexity, limits.Complexity},
+			{"nesting", m.Nesting, limits.Nesting},
+			{"statements", m.Statements, limits.Statements},
+			{"parameters", m.Parameters, limits.Parameters},
+			{"soql", m.SOQL, limits.SOQL},
+			{"dml", m.DML, limits.DML},
+		} {
+			if c.limit > 0 && c.value > c.limit {
+				violations = append(violations, Violation{File: file, Line: line, Name: name, Metric: c.metric, Value: c.value, Limit: c.limit})
+			}
+		}
+	}
+	for _, f := range files {
+		for _, t := range f.Types {
+			for _, m := range t.Methods {
+				check(f.File, t.Name+"."+m.Name, m.Line, m.Metrics)
+			}
+		}
+	}
+	return violations
+}
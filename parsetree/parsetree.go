@@ -0,0 +1,195 @@
+// Package parsetree serializes the ANTLR parse tree of Apex source as JSON
+// for tools written in other languages.  The format is described by
+// Schema and versioned by SchemaVersion.
+package parsetree
+
+import (
+	_ "embed"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// SchemaVersion is incremented whenever the JSON format changes in a way
+// that is not backwards compatible.
+const SchemaVersion = 1
+
+// Schema is the JSON Schema describing Document.
+//
+//go:embed schema.json
+var Schema string
+
+// Node kinds.
+const (
+	RuleNode  = "rule"
+	TokenNode = "token"
+)
+
+// Token channels.
+const (
+	DefaultChannel    = "default"
+	WhitespaceChannel = "whitespace"
+	CommentsChannel   = "comments"
+)
+
+// Document is the top-level JSON object.
+type Document struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	File          string `json:"file,omitempty"`
+	Tree          *Node  `json:"tree"`
+}
+
+// Position is a location in source.  Line and Column are 1-based; Offset is
+// the 0-based character offset.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Node is a parser rule or a token.  Rule nodes have Rule, Alternative and
+// Children; token nodes have Token, Text and Channel.  End is the position
+// just past the last character.
+type Node struct {
+	Kind        string   `json:"kind"`
+	Rule        string   `json:"rule,omitempty"`
+	Alternative string   `json:"alternative,omitempty"`
+	Token       string   `json:"token,omitempty"`
+	Text        string   `json:"text,omitempty"`
+	Channel     string   `json:"channel,omitempty"`
+	Start       Position `json:"start"`
+	End         Position `json:"end"`
+	Children    []*Node  `json:"children,omitempty"`
+}
+
+// Parse reads Apex source and returns its parse tree.  If hidden is set,
+// whitespace and comment tokens are included before the token they precede.
+func Parse(filename string, reader io.Reader, hidden bool) (*Document, error) {
+	tree, stream, err := formatter.Parse(filename, reader)
+	if err != nil {
+		return nil, err
+	}
+	c := &converter{
+		tokens:     stream,
+		ruleNames:  tree.GetParser().GetRuleNames(),
+		tokenNames: stream.GetTokenSource().(*parser.ApexLexer).SymbolicNames,
+		hidden:     hidden,
+		emitted:    make(map[int]struct{}),
+	}
+	return &Document{
+		SchemaVersion: SchemaVersion,
+		File:          filename,
+		Tree:          c.convert(tree)[0],
+	}, nil
+}
+
+type converter struct {
+	tokens     *antlr.CommonTokenStream
+	ruleNames  []string
+	tokenNames []string
+	hidden     bool
+	emitted    map[int]struct{}
+}
+
+// convert returns the node for tree.  Terminals are preceded by any hidden
+// tokens that have not been emitted yet.
+func (c *converter) convert(tree antlr.Tree) []*Node {
+	switch t := tree.(type) {
+	case antlr.TerminalNode:
+		return c.token(t.GetSymbol())
+	case antlr.ParserRuleContext:
+		n := &Node{
+			Kind: RuleNode,
+			Rule: c.ruleNames[t.GetRuleIndex()],
+		}
+		if alt := alternative(t); !strings.EqualFold(alt, n.Rule) {
+			n.Alternative = alt
+		}
+		for _, child := range t.GetChildren() {
+			n.Children = append(n.Children, c.convert(child)...)
+		}
+		n.Start = start(t.GetStart())
+		n.End = n.Start
+		if stop := t.GetStop(); stop != nil && stop.GetTokenIndex() >= t.GetStart().GetTokenIndex() {
+			n.End = end(stop)
+		}
+		return []*Node{n}
+	}
+	return nil
+}
+
+func (c *converter) token(t antlr.Token) []*Node {
+	nodes := []*Node{}
+	if c.hidden {
+		for _, h := range c.tokens.GetHiddenTokensToLeft(t.GetTokenIndex(), -1) {
+			if _, seen := c.emitted[h.GetTokenIndex()]; !seen {
+				c.emitted[h.GetTokenIndex()] = struct{}{}
+				nodes = append(nodes, c.tokenNode(h))
+			}
+		}
+	}
+	return append(nodes, c.tokenNode(t))
+}
+
+func (c *converter) tokenNode(t antlr.Token) *Node {
+	n := &Node{
+		Kind:    TokenNode,
+		Channel: channel(t.GetChannel()),
+		Start:   start(t),
+		End:     end(t),
+	}
+	if t.GetTokenType() == antlr.TokenEOF {
+		n.Token = "EOF"
+		return n
+	}
+	if tt := t.GetTokenType(); tt > 0 && tt < len(c.tokenNames) {
+		n.Token = c.tokenNames[tt]
+	}
+	n.Text = t.GetText()
+	return n
+}
+
+func channel(ch int) string {
+	switch ch {
+	case formatter.WHITESPACE_CHANNEL:
+		return WhitespaceChannel
+	case formatter.COMMENTS_CHANNEL:
+		return CommentsChannel
+	}
+	return DefaultChannel
+}
+
+// alternative returns the label of the rule alternative ctx matched, e.g.
+// dotExpression for an expression, derived from its context type.
+func alternative(ctx antlr.ParserRuleContext) string {
+	name := strings.TrimSuffix(reflect.TypeOf(ctx).Elem().Name(), "Context")
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func start(t antlr.Token) Position {
+	return Position{Line: t.GetLine(), Column: t.GetColumn() + 1, Offset: t.GetStart()}
+}
+
+// end returns the position just past the last character of t.
+func end(t antlr.Token) Position {
+	p := start(t)
+	if t.GetTokenType() == antlr.TokenEOF {
+		return p
+	}
+	text := t.GetText()
+	p.Offset = t.GetStop() + 1
+	if i := strings.LastIndex(text, "\n"); i >= 0 {
+		p.Line += strings.Count(text, "\n")
+		p.Column = len([]rune(text[i+1:])) + 1
+	} else {
+		p.Column += len([]rune(text))
+	}
+	return p
+}
@@ -0,0 +1,86 @@
+package parsetree
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// find returns the first node in depth-first order matching fn.
+func find(n *Node, fn func(*Node) bool) *Node {
+	if fn(n) {
+		return n
+	}
+	for _, c := range n.Children {
+		if f := find(c, fn); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func tokens(n *Node) []*Node {
+	if n.Kind == TokenNode {
+		return []*Node{n}
+	}
+	result := []*Node{}
+	for _, c := range n.Children {
+		result = append(result, tokens(c)...)
+	}
+	return result
+}
+
+func TestParse(t *testing.T) {
+	src := "class A {\n\t// count\n\tInteger x = a.b();\n}\n"
+	doc, err := Parse("", strings.NewReader(src), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if doc.SchemaVersion != SchemaVersion || doc.Tree.Rule != "compilationUnit" {
+		t.Errorf("unexpected document: %#v", doc)
+	}
+	dot := find(doc.Tree, func(n *Node) bool { return n.Alternative == "dotExpression" })
+	if dot == nil || dot.Rule != "expression" {
+		t.Fatalf("expected dotExpression alternative, got %#v", dot)
+	}
+	if dot.Start != (Position{Line: 3, Column: 14, Offset: 33}) || dot.End != (Position{Line: 3, Column: 19, Offset: 38}) {
+		t.Errorf("unexpected range: %#v %#v", dot.Start, dot.End)
+	}
+	text := []string{}
+	for _, tok := range tokens(doc.Tree) {
+		text = append(text, tok.Text)
+	}
+	if strings.Join(text, " ") != "class A { Integer x = a . b ( ) ; } " {
+		t.Errorf("unexpected tokens: %q", text)
+	}
+	if last := tokens(doc.Tree)[len(text)-1]; last.Token != "EOF" {
+		t.Errorf("expected EOF token, got %#v", last)
+	}
+
+	doc, err = Parse("", strings.NewReader(src), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b strings.Builder
+	for _, tok := range tokens(doc.Tree) {
+		b.WriteString(tok.Text)
+	}
+	if b.String() != src {
+		t.Errorf("expected hidden tokens to reproduce source, got %q", b.String())
+	}
+	comment := find(doc.Tree, func(n *Node) bool { return n.Channel == CommentsChannel })
+	if comment == nil || comment.Text != "// count" || comment.End != (Position{Line: 2, Column: 10, Offset: 19}) {
+		t.Errorf("unexpected comment: %#v", comment)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	var schema map[string]interface{}
+	if err := json.Unmarshal([]byte(Schema), &schema); err != nil {
+		t.Fatalf("invalid schema: %s", err)
+	}
+	version := schema["properties"].(map[string]interface{})["schemaVersion"].(map[string]interface{})["const"]
+	if version != float64(SchemaVersion) {
+		t.Errorf("schema version %v does not match SchemaVersion %d", version, SchemaVersion)
+	}
+}
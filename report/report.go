@@ -0,0 +1,221 @@
+// Package report writes apexfmt findings in machine-readable formats for CI
+// and code scanning tools.
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	// FormattingRule identifies files whose formatting differs from apexfmt's.
+	FormattingRule = "formatting"
+	// SyntaxErrorRule identifies files that cannot be parsed.
+	SyntaxErrorRule = "syntax-error"
+)
+
+// Formats lists the supported values for --format.
+var Formats = []string{"text", "json", "sarif", "checkstyle"}
+
+// Entry is a single finding.  Line and Column are 1-based.
+type Entry struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e Entry) severity() string {
+	if e.Rule == SyntaxErrorRule {
+		return "error"
+	}
+	return "warning"
+}
+
+// Valid reports whether format is one of Formats.
+func Valid(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes entries to w as json, sarif, or checkstyle.  Text output
+// differs between commands, so it is left to the caller.
+func Write(w io.Writer, format string, entries []Entry) error {
+	switch format {
+	case "json":
+		return writeJSON(w, entries)
+	case "sarif":
+		return writeSARIF(w, entries)
+	case "checkstyle":
+		return writeCheckstyle(w, entries)
+	}
+	return fmt.Errorf("Unsupported format: %s", format)
+}
+
+func writeJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+func writeSARIF(w io.Writer, entries []Entry) error {
+	rules := []sarifRule{}
+	index := make(map[string]int)
+	for _, e := range entries {
+		if _, ok := index[e.Rule]; !ok {
+			index[e.Rule] = 0
+			rules = append(rules, sarifRule{ID: e.Rule})
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	for i, r := range rules {
+		index[r.ID] = i
+	}
+	results := []sarifResult{}
+	for _, e := range entries {
+		results = append(results, sarifResult{
+			RuleID:    e.Rule,
+			RuleIndex: index[e.Rule],
+			Level:     e.severity(),
+			Message:   sarifMessage{Text: e.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: e.File},
+					Region:           sarifRegion{StartLine: e.Line, StartColumn: e.Column},
+				},
+			}},
+		})
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "apexfmt",
+				InformationURI: "https://github.com/octoberswimmer/apexfmt",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func writeCheckstyle(w io.Writer, entries []Entry) error {
+	report := checkstyleReport{Version: "4.3"}
+	files := make(map[string]int)
+	for _, e := range entries {
+		i, ok := files[e.File]
+		if !ok {
+			i = len(report.Files)
+			files[e.File] = i
+			report.Files = append(report.Files, checkstyleFile{Name: e.File})
+		}
+		report.Files[i].Errors = append(report.Files[i].Errors, checkstyleError{
+			Line:     e.Line,
+			Column:   e.Column,
+			Severity: e.severity(),
+			Message:  e.Message,
+			Source:   "apexfmt." + e.Rule,
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,138 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	entries := []Entry{
+		{File: "classes/Foo.cls", Line: 1, Column: 1, Rule: FormattingRule, Message: "formatting differs from apexfmt's"},
+		{File: "classes/Bar.cls", Line: 3, Column: 5, Rule: SyntaxErrorRule, Message: "missing ';' at '}'"},
+	}
+	tests :=
+		[]struct {
+			format string
+			output string
+		}{
+			{
+				"json",
+				`[
+  {
+    "file": "classes/Foo.cls",
+    "line": 1,
+    "column": 1,
+    "rule": "formatting",
+    "message": "formatting differs from apexfmt's"
+  },
+  {
+    "file": "classes/Bar.cls",
+    "line": 3,
+    "column": 5,
+    "rule": "syntax-error",
+    "message": "missing ';' at '}'"
+  }
+]
+`},
+			{
+				"sarif",
+				`{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "apexfmt",
+          "informationUri": "https://github.com/octoberswimmer/apexfmt",
+          "rules": [
+            {
+              "id": "formatting"
+            },
+            {
+              "id": "syntax-error"
+            }
+          ]
+        }
+      },
+      "results": [
+        {
+          "ruleId": "formatting",
+          "ruleIndex": 0,
+          "level": "warning",
+          "message": {
+            "text": "formatting differs from apexfmt's"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "classes/Foo.cls"
+                },
+                "region": {
+                  "startLine": 1,
+                  "startColumn": 1
+                }
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "syntax-error",
+          "ruleIndex": 1,
+          "level": "error",
+          "message": {
+            "text": "missing ';' at '}'"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "classes/Bar.cls"
+                },
+                "region": {
+                  "startLine": 3,
+                  "startColumn": 5
+                }
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`},
+			{
+				"checkstyle",
+				`<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="classes/Foo.cls">
+    <error line="1" column="1" severity="warning" message="formatting differs from apexfmt&#39;s" source="apexfmt.formatting"></error>
+  </file>
+  <file name="classes/Bar.cls">
+    <error line="3" column="5" severity="error" message="missing &#39;;&#39; at &#39;}&#39;" source="apexfmt.syntax-error"></error>
+  </file>
+</checkstyle>
+`},
+		}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := Write(&out, tt.format, entries); err != nil {
+			t.Errorf("Unexpected error: %s", err.Error())
+		}
+		if out.String() != tt.output {
+			t.Errorf("unexpected %s output.  expected:\n%s\ngot:\n%s\n", tt.format, tt.output, out.String())
+		}
+	}
+}
+
+func TestWriteEmptyJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := Write(&out, "json", nil); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	if out.String() != "[]\n" {
+		t.Errorf("unexpected output for no entries: %q", out.String())
+	}
+}
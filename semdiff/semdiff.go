@@ -0,0 +1,520 @@
+// Package semdiff compares the declarations and statements of two Apex
+// sources, ignoring whitespace, comments, letter case outside of string
+// literals, and the order of modifiers and members.
+package semdiff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/ast"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Change kinds.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is a difference between the old and new source.  OldLine is zero
+// for additions and NewLine is zero for removals.  Changes within a changed
+// method, constructor, accessor, initializer or trigger are listed in
+// Statements.
+type Change struct {
+	Kind       string    `json:"kind"`
+	What       string    `json:"what"`
+	Name       string    `json:"name"`
+	OldLine    int       `json:"oldLine,omitempty"`
+	NewLine    int       `json:"newLine,omitempty"`
+	Statements []*Change `json:"statements,omitempty"`
+}
+
+// Source is a parsed Apex file.
+type Source struct {
+	file *ast.File
+	src  []byte
+}
+
+// Parse parses Apex source.  reader is used if filename is empty.
+func Parse(filename string, reader io.Reader) (*Source, error) {
+	src, err := formatter.ReadSource(filename, reader)
+	if err != nil {
+		return nil, err
+	}
+	tree, stream, err := formatter.ParseSource(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{file: ast.Convert(tree, stream), src: src}, nil
+}
+
+// Diff returns the changes from old to new.  No changes means that the
+// sources differ only in formatting and comments.
+func Diff(old, new *Source) []*Change {
+	d := &differ{old: old, new: new}
+	return d.decl("", old.file.Decl, new.file.Decl)
+}
+
+type differ struct {
+	old, new *Source
+}
+
+// member is a declaration identified by its kind and name.
+type member struct {
+	what string
+	name string
+	key  string
+	decl ast.Decl
+	// text is the canonical text of the declaration, excluding the bodies
+	// of inner types
+	text string
+	line int
+}
+
+func (d *differ) decl(scope string, old, new ast.Decl) []*Change {
+	oldMember := d.old.describe(scope, old)
+	newMember := d.new.describe(scope, new)
+	if oldMember.key != newMember.key {
+		return []*Change{
+			{Kind: Removed, What: oldMember.what, Name: oldMember.name, OldLine: oldMember.line},
+			{Kind: Added, What: newMember.what, Name: newMember.name, NewLine: newMember.line},
+		}
+	}
+	return d.compare(oldMember, newMember)
+}
+
+// compare returns the changes between two declarations with the same key.
+func (d *differ) compare(old, new *member) []*Change {
+	var changes []*Change
+	if old.text != new.text {
+		c := &Change{Kind: Changed, What: old.what, Name: old.name, OldLine: old.line, NewLine: new.line}
+		c.Statements = d.statements(body(old.decl), body(new.decl))
+		changes = append(changes, c)
+	}
+	oldMembers, newMembers := d.old.members(old), d.new.members(new)
+	if oldMembers == nil && newMembers == nil {
+		return changes
+	}
+	index := make(map[string]*member)
+	for _, m := range newMembers {
+		index[m.key] = m
+	}
+	seen := make(map[string]bool)
+	for _, o := range oldMembers {
+		seen[o.key] = true
+		n, ok := index[o.key]
+		if !ok {
+			changes = append(changes, &Change{Kind: Removed, What: o.what, Name: o.name, OldLine: o.line})
+			continue
+		}
+		changes = append(changes, d.compare(o, n)...)
+	}
+	for _, n := range newMembers {
+		if !seen[n.key] {
+			changes = append(changes, &Change{Kind: Added, What: n.what, Name: n.name, NewLine: n.line})
+		}
+	}
+	return changes
+}
+
+// members returns the members of a type declaration.
+func (s *Source) members(m *member) []*member {
+	scope := m.name
+	var members []*member
+	add := func(d ast.Decl) {
+		members = append(members, s.describe(scope, d))
+	}
+	switch d := m.decl.(type) {
+	case *ast.ClassDecl:
+		initializers := 0
+		for _, decl := range d.Members {
+			if f, ok := decl.(*ast.FieldDecl); ok {
+				// each variable of a field declaration is a member
+				for _, v := range f.Vars {
+					members = append(members, &member{
+						what: "field",
+						name: scope + "." + v.Name,
+						key:  "field " + strings.ToLower(v.Name),
+						decl: f,
+						text: s.canonical(f.Span.Start, f.Type.End) + " " + s.canonical(v.Start, v.End),
+						line: v.Pos().Line,
+					})
+				}
+				continue
+			}
+			if i, ok := decl.(*ast.InitializerDecl); ok {
+				// initializers are matched by position
+				initializers++
+				m := s.describe(scope, i)
+				m.key = fmt.Sprintf("%s %d", m.key, initializers)
+				members = append(members, m)
+				continue
+			}
+			add(decl)
+		}
+	case *ast.InterfaceDecl:
+		for _, method := range d.Methods {
+			add(method)
+		}
+	case *ast.TriggerDecl:
+		for _, stmt := range d.Body {
+			if decl, ok := stmt.(*ast.DeclStmt); ok {
+				add(decl.Decl)
+			}
+		}
+	default:
+		return nil
+	}
+	return members
+}
+
+func (s *Source) describe(scope string, d ast.Decl) *member {
+	qualify := func(name string) string {
+		if scope == "" {
+			return name
+		}
+		return scope + "." + name
+	}
+	params := func(ps []*ast.Param) string {
+		types := []string{}
+		for _, p := range ps {
+			types = append(types, p.Type.Name)
+		}
+		return "(" + strings.Join(types, ", ") + ")"
+	}
+	m := &member{decl: d, line: d.Pos().Line, text: s.canonical(d.Pos(), d.EndPos())}
+	switch d := d.(type) {
+	case *ast.ClassDecl:
+		m.what, m.name = "class", qualify(d.Name)
+		m.text = s.header(d.Span, d.Members)
+	case *ast.InterfaceDecl:
+		m.what, m.name = "interface", qualify(d.Name)
+		members := []ast.Decl{}
+		for _, method := range d.Methods {
+			members = append(members, method)
+		}
+		m.text = s.header(d.Span, members)
+	case *ast.EnumDecl:
+		m.what, m.name = "enum", qualify(d.Name)
+	case *ast.TriggerDecl:
+		m.what, m.name = "trigger", d.Name
+		// methods declared in the trigger are compared as members
+		body := []ast.Decl{}
+		for _, stmt := range d.Body {
+			if decl, ok := stmt.(*ast.DeclStmt); ok {
+				body = append(body, decl.Decl)
+			}
+		}
+		m.text = s.header(d.Span, body)
+	case *ast.PropertyDecl:
+		m.what, m.name = "property", qualify(d.Name)
+	case *ast.ConstructorDecl:
+		m.what, m.name = "constructor", qualify(d.Name)+params(d.Params)
+	case *ast.MethodDecl:
+		m.what, m.name = "method", qualify(d.Name)+params(d.Params)
+	case *ast.InitializerDecl:
+		m.what, m.name = "initializer", qualify("{}")
+		if d.Static {
+			m.what, m.name = "static initializer", qualify("static {}")
+		}
+	}
+	m.key = m.what + " " + strings.ToLower(m.name)
+	return m
+}
+
+// header returns the canonical text of a declaration with the given
+// members removed.
+func (s *Source) header(span ast.Span, members []ast.Decl) string {
+	var parts []string
+	start := span.Start
+	sort.Slice(members, func(i, j int) bool { return before(members[i].Pos(), members[j].Pos()) })
+	for _, m := range members {
+		parts = append(parts, s.canonical(start, m.Pos()))
+		start = m.EndPos()
+	}
+	parts = append(parts, s.canonical(start, span.End))
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
+
+func before(a, b ast.Pos) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// body returns the statements of a method, constructor, accessor,
+// initializer or trigger.
+func body(d ast.Decl) []ast.Stmt {
+	switch d := d.(type) {
+	case *ast.MethodDecl:
+		if d.Body != nil {
+			return d.Body.Stmts
+		}
+	case *ast.ConstructorDecl:
+		return d.Body.Stmts
+	case *ast.InitializerDecl:
+		return d.Body.Stmts
+	case *ast.TriggerDecl:
+		// methods declared in the trigger are compared as members
+		var stmts []ast.Stmt
+		for _, stmt := range d.Body {
+			if _, ok := stmt.(*ast.DeclStmt); !ok {
+				stmts = append(stmts, stmt)
+			}
+		}
+		return stmts
+	case *ast.PropertyDecl:
+		var stmts []ast.Stmt
+		for _, a := range []*ast.Accessor{d.Getter, d.Setter} {
+			if a != nil && a.Body != nil {
+				stmts = append(stmts, a.Body)
+			}
+		}
+		return stmts
+	}
+	return nil
+}
+
+// statements diffs two statement lists.  Statements are compared by their
+// canonical text; where statements were both removed and added, a removed
+// statement and a later added statement of the same kind are reported as a
+// change.
+func (d *differ) statements(old, new []ast.Stmt) []*Change {
+	oldText := make([]string, len(old))
+	for i, s := range old {
+		oldText[i] = d.old.canonical(s.Pos(), s.EndPos())
+	}
+	newText := make([]string, len(new))
+	for i, s := range new {
+		newText[i] = d.new.canonical(s.Pos(), s.EndPos())
+	}
+	// longest common subsequence
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if oldText[i] == newText[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var changes []*Change
+	var removed, added []ast.Stmt
+	flush := func() {
+		paired := make(map[int]bool)
+		next := 0
+		for _, r := range removed {
+			match := -1
+			for k := next; k < len(added); k++ {
+				if statementKind(added[k]) == statementKind(r) {
+					match = k
+					break
+				}
+			}
+			if match < 0 {
+				changes = append(changes, &Change{Kind: Removed, What: statementKind(r), Name: d.old.summary(r), OldLine: r.Pos().Line})
+				continue
+			}
+			paired[match] = true
+			next = match + 1
+			changes = append(changes, &Change{Kind: Changed, What: statementKind(r), Name: d.new.summary(added[match]), OldLine: r.Pos().Line, NewLine: added[match].Pos().Line})
+		}
+		for k, a := range added {
+			if !paired[k] {
+				changes = append(changes, &Change{Kind: Added, What: statementKind(a), Name: d.new.summary(a), NewLine: a.Pos().Line})
+			}
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && oldText[i] == newText[j]:
+			flush()
+			i++
+			j++
+		case j < len(new) && (i == len(old) || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, new[j])
+			j++
+		default:
+			removed = append(removed, old[i])
+			i++
+		}
+	}
+	flush()
+	return changes
+}
+
+func statementKind(s ast.Stmt) string {
+	switch s := s.(type) {
+	case *ast.Block:
+		return "block"
+	case *ast.LocalVarStmt:
+		return "local variable"
+	case *ast.ExprStmt:
+		return "expression"
+	case *ast.IfStmt:
+		return "if"
+	case *ast.SwitchStmt:
+		return "switch"
+	case *ast.ForStmt, *ast.ForEachStmt:
+		return "for"
+	case *ast.WhileStmt:
+		return "while"
+	case *ast.DoWhileStmt:
+		return "do"
+	case *ast.TryStmt:
+		return "try"
+	case *ast.ReturnStmt:
+		return "return"
+	case *ast.ThrowStmt:
+		return "throw"
+	case *ast.BreakStmt:
+		return "break"
+	case *ast.ContinueStmt:
+		return "continue"
+	case *ast.DMLStmt:
+		return s.Op
+	case *ast.RunAsStmt:
+		return "runAs"
+	}
+	return "statement"
+}
+
+// summary returns the first line of a statement's source, shortened.
+func (s *Source) summary(stmt ast.Stmt) string {
+	text := s.text(stmt.Pos(), stmt.EndPos())
+	text, _, _ = strings.Cut(text, "\n")
+	text = strings.TrimSpace(text)
+	if len(text) > 60 {
+		text = text[:57] + "..."
+	}
+	return text
+}
+
+// offset returns the byte offset of p in the source.
+func (s *Source) offset(p ast.Pos) int {
+	off := 0
+	for line := 1; line < p.Line; line++ {
+		i := bytes.IndexByte(s.src[off:], '\n')
+		if i < 0 {
+			return len(s.src)
+		}
+		off += i + 1
+	}
+	for col := 1; col < p.Column && off < len(s.src); col++ {
+		_, size := utf8.DecodeRune(s.src[off:])
+		off += size
+	}
+	return off
+}
+
+func (s *Source) text(start, end ast.Pos) string {
+	from, to := s.offset(start), s.offset(end)
+	if to < from {
+		return ""
+	}
+	return string(s.src[from:to])
+}
+
+// canonical returns the tokens of the source between start and end,
+// separated by single spaces, with comments removed, keywords and
+// identifiers in lower case, and modifiers sorted.
+func (s *Source) canonical(start, end ast.Pos) string {
+	lexer := parser.NewApexLexer(antlr.NewInputStream(s.text(start, end)))
+	var tokens []antlr.Token
+	for t := lexer.NextToken(); t.GetTokenType() != antlr.TokenEOF; t = lexer.NextToken() {
+		if t.GetChannel() == antlr.TokenDefaultChannel {
+			tokens = append(tokens, t)
+		}
+	}
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.GetText()
+		if t.GetTokenType() != parser.ApexLexerStringLiteral {
+			texts[i] = strings.ToLower(texts[i])
+		}
+	}
+	return strings.Join(sortModifiers(texts), " ")
+}
+
+var modifierKeywords = map[string]bool{
+	"global": true, "public": true, "protected": true, "private": true,
+	"static": true, "final": true, "abstract": true, "virtual": true,
+	"override": true, "transient": true, "testmethod": true, "webservice": true,
+	"with": true, "without": true, "inherited": true, "sharing": true,
+}
+
+// sortModifiers sorts the annotations and modifier keywords at the start of
+// a declaration.
+func sortModifiers(tokens []string) []string {
+	var modifiers []string
+	i := 0
+	for i < len(tokens) {
+		switch {
+		case tokens[i] == "@":
+			j := i + 1
+			for j < len(tokens) && (j == i+1 || tokens[j] == "." || tokens[j-1] == ".") {
+				j++
+			}
+			if j < len(tokens) && tokens[j] == "(" {
+				for j < len(tokens) && tokens[j] != ")" {
+					j++
+				}
+				j++
+			}
+			j = min(j, len(tokens))
+			modifiers = append(modifiers, strings.Join(tokens[i:j], " "))
+			i = j
+		case modifierKeywords[tokens[i]]:
+			modifiers = append(modifiers, tokens[i])
+			i++
+		default:
+			sort.Strings(modifiers)
+			return append(modifiers, tokens[i:]...)
+		}
+	}
+	sort.Strings(modifiers)
+	return modifiers
+}
+
+// Write writes changes to w, one per line, with statement changes indented
+// below their declaration.
+func Write(w io.Writer, changes []*Change) error {
+	var b strings.Builder
+	var write func(indent string, c *Change)
+	write = func(indent string, c *Change) {
+		var line string
+		switch c.Kind {
+		case Added:
+			line = fmt.Sprintf("line %d", c.NewLine)
+		case Removed:
+			line = fmt.Sprintf("line %d", c.OldLine)
+		default:
+			line = fmt.Sprintf("line %d -> %d", c.OldLine, c.NewLine)
+		}
+		if indent == "" {
+			fmt.Fprintf(&b, "%s %s %s (%s)\n", c.Kind, c.What, c.Name, line)
+		} else {
+			fmt.Fprintf(&b, "%s%s %s statement: %s (%s)\n", indent, c.Kind, c.What, c.Name, line)
+		}
+		for _, s := range c.Statements {
+			write(indent+"\t", s)
+		}
+	}
+	for _, c := range changes {
+		write("", c)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,102 @@
+package semdiff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func diff(t *testing.T, old, new string) string {
+	t.Helper()
+	o, err := Parse("", strings.NewReader(old))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	n, err := Parse("", strings.NewReader(new))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var out bytes.Buffer
+	if err := Write(&out, Diff(o, n)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return out.String()
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		old    string
+		new    string
+		output string
+	}{
+		{
+			`public with sharing class Foo {
+	// the count
+	static public Integer count;
+	public void run() { if (x) { return; } }
+}`,
+			`public  with sharing  class Foo
+{
+	public void run() {
+		IF (X) {
+			return;
+		}
+	}
+
+	/** The count. */
+	public static Integer count;
+}`,
+			``,
+		},
+		{
+			`public with sharing class Foo {
+	private Integer count;
+	public void run(String name) {
+		if (name == null) { return; }
+		insert new Account(Name = name);
+		System.debug('x');
+	}
+	public void old() {}
+}`,
+			`public without sharing class Foo {
+	private Integer count, total;
+	public void run(String name) {
+		if (name == null) {
+			return;
+		}
+		update new Account(Name = name);
+		System.debug('X');
+	}
+	public class Inner {}
+}`,
+			`changed class Foo (line 1 -> 1)
+changed method Foo.run(String) (line 3 -> 3)
+	removed insert statement: insert new Account(Name = name); (line 5)
+	changed expression statement: System.debug('X'); (line 6 -> 8)
+	added update statement: update new Account(Name = name); (line 7)
+removed method Foo.old() (line 8)
+added field Foo.total (line 2)
+added class Foo.Inner (line 10)
+`,
+		},
+		{
+			`trigger T on Account (before insert) {
+	run(Trigger.new);
+	void run(List<Account> accounts) {}
+}`,
+			`trigger T on Account (before insert, before update) {
+	run(Trigger.new);
+	void run(List<Account> accounts) { System.debug(accounts); }
+}`,
+			`changed trigger T (line 1 -> 1)
+changed method T.run(List<Account>) (line 3 -> 3)
+	added expression statement: System.debug(accounts); (line 3)
+`,
+		},
+	}
+	for _, tt := range tests {
+		if out := diff(t, tt.old, tt.new); out != tt.output {
+			t.Errorf("unexpected diff.  expected:\n%s\ngot:\n%s", tt.output, out)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+// Package symbols extracts an outline of the types and members declared in
+// Apex source, for use by editors and code search.
+package symbols
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/octoberswimmer/apexfmt/formatter"
+	"github.com/octoberswimmer/apexfmt/parser"
+)
+
+// Position is a location in source.  Line and Column are 1-based.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Range spans a declaration from its first character to its last.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Parameter is a method or constructor parameter.
+type Parameter struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Modifiers []string `json:"modifiers,omitempty"`
+}
+
+// Symbol is a declared type or member.  Type is the return type of methods
+// and the declared type of fields and properties.
+type Symbol struct {
+	Kind        string      `json:"kind"`
+	Name        string      `json:"name"`
+	Modifiers   []string    `json:"modifiers,omitempty"`
+	Annotations []string    `json:"annotations,omitempty"`
+	Type        string      `json:"type,omitempty"`
+	Parameters  []Parameter `json:"parameters,omitempty"`
+	Range       Range       `json:"range"`
+	Children    []*Symbol   `json:"children,omitempty"`
+}
+
+// Symbol kinds.
+const (
+	Class        = "class"
+	Interface    = "interface"
+	Enum         = "enum"
+	EnumConstant = "enumConstant"
+	Trigger      = "trigger"
+	Field        = "field"
+	Property     = "property"
+	Constructor  = "constructor"
+	Method       = "method"
+)
+
+// Parse reads Apex source and returns its top-level symbol.
+func Parse(filename string, reader io.Reader) (*Symbol, error) {
+	tree, stream, err := formatter.Parse(filename, reader)
+	if err != nil {
+		return nil, err
+	}
+	e := &extractor{tokens: stream}
+	if trigger := tree.TriggerUnit(); trigger != nil {
+		return &Symbol{
+			Kind:  Trigger,
+			Name:  trigger.Id(0).GetText(),
+			Type:  trigger.Id(1).GetText(),
+			Range: rangeOf(trigger),
+		}, nil
+	}
+	t := tree.TypeDeclaration()
+	return e.typeDeclaration(t, t.AllModifier(), t.ClassDeclaration(), t.InterfaceDeclaration(), t.EnumDeclaration()), nil
+}
+
+type extractor struct {
+	tokens *antlr.CommonTokenStream
+}
+
+func (e *extractor) typeDeclaration(ctx antlr.ParserRuleContext, modifiers []parser.IModifierContext, class parser.IClassDeclarationContext, iface parser.IInterfaceDeclarationContext, enum parser.IEnumDeclarationContext) *Symbol {
+	s := e.symbol(ctx, modifiers)
+	switch {
+	case class != nil:
+		s.Kind = Class
+		s.Name = class.Id().GetText()
+		for _, d := range class.ClassBody().AllClassBodyDeclaration() {
+			if child := e.classBodyDeclaration(d); child != nil {
+				s.Children = append(s.Children, child...)
+			}
+		}
+	case iface != nil:
+		s.Kind = Interface
+		s.Name = iface.Id().GetText()
+		for _, m := range iface.InterfaceBody().AllInterfaceMethodDeclaration() {
+			method := e.symbol(m, m.AllModifier())
+			method.Kind = Method
+			method.Name = m.Id().GetText()
+			method.Type = returnType(m.TypeRef())
+			method.Parameters = e.parameters(m.FormalParameters())
+			s.Children = append(s.Children, method)
+		}
+	case enum != nil:
+		s.Kind = Enum
+		s.Name = enum.Id().GetText()
+		if constants := enum.EnumConstants(); constants != nil {
+			for _, id := range constants.AllId() {
+				s.Children = append(s.Children, &Symbol{
+					Kind:  EnumConstant,
+					Name:  id.GetText(),
+					Range: rangeOf(id),
+				})
+			}
+		}
+	}
+	return s
+}
+
+func (e *extractor) classBodyDeclaration(ctx parser.IClassBodyDeclarationContext) []*Symbol {
+	member := ctx.MemberDeclaration()
+	if member == nil {
+		return nil
+	}
+	modifiers := ctx.AllModifier()
+	switch {
+	case member.ClassDeclaration() != nil || member.InterfaceDeclaration() != nil || member.EnumDeclaration() != nil:
+		return []*Symbol{e.typeDeclaration(ctx, modifiers, member.ClassDeclaration(), member.InterfaceDeclaration(), member.EnumDeclaration())}
+	case member.FieldDeclaration() != nil:
+		f := member.FieldDeclaration()
+		fields := []*Symbol{}
+		for _, v := range f.VariableDeclarators().AllVariableDeclarator() {
+			s := e.symbol(ctx, modifiers)
+			s.Kind = Field
+			s.Name = v.Id().GetText()
+			s.Type = typeText(f.TypeRef())
+			fields = append(fields, s)
+		}
+		return fields
+	case member.PropertyDeclaration() != nil:
+		p := member.PropertyDeclaration()
+		s := e.symbol(ctx, modifiers)
+		s.Kind = Property
+		s.Name = p.Id().GetText()
+		s.Type = typeText(p.TypeRef())
+		return []*Symbol{s}
+	case member.ConstructorDeclaration() != nil:
+		c := member.ConstructorDeclaration()
+		s := e.symbol(ctx, modifiers)
+		s.Kind = Constructor
+		s.Name = c.QualifiedName().GetText()
+		s.Parameters = e.parameters(c.FormalParameters())
+		return []*Symbol{s}
+	case member.MethodDeclaration() != nil:
+		m := member.MethodDeclaration()
+		s := e.symbol(ctx, modifiers)
+		s.Kind = Method
+		s.Name = m.Id().GetText()
+		s.Type = returnType(m.TypeRef())
+		s.Parameters = e.parameters(m.FormalParameters())
+		return []*Symbol{s}
+	}
+	return nil
+}
+
+// symbol returns a Symbol with the modifiers, annotations and range of ctx.
+func (e *extractor) symbol(ctx antlr.ParserRuleContext, modifiers []parser.IModifierContext) *Symbol {
+	s := &Symbol{Range: rangeOf(ctx)}
+	s.Modifiers, s.Annotations = e.modifiers(modifiers)
+	return s
+}
+
+func (e *extractor) modifiers(ctxs []parser.IModifierContext) ([]string, []string) {
+	var modifiers, annotations []string
+	for _, m := range ctxs {
+		if a := m.Annotation(); a != nil {
+			annotations = append(annotations, e.tokens.GetTextFromRuleContext(a))
+			continue
+		}
+		words := []string{}
+		for _, w := range m.GetChildren() {
+			words = append(words, w.(antlr.TerminalNode).GetText())
+		}
+		modifiers = append(modifiers, strings.Join(words, " "))
+	}
+	return modifiers, annotations
+}
+
+func (e *extractor) parameters(ctx parser.IFormalParametersContext) []Parameter {
+	var params []Parameter
+	if list := ctx.FormalParameterList(); list != nil {
+		for _, p := range list.AllFormalParameter() {
+			modifiers, _ := e.modifiers(p.AllModifier())
+			params = append(params, Parameter{
+				Name:      p.Id().GetText(),
+				Type:      typeText(p.TypeRef()),
+				Modifiers: modifiers,
+			})
+		}
+	}
+	return params
+}
+
+func typeText(ctx antlr.ParserRuleContext) string {
+	return strings.ReplaceAll(ctx.GetText(), ",", ", ")
+}
+
+func returnType(ctx parser.ITypeRefContext) string {
+	if ctx == nil {
+		return "void"
+	}
+	return typeText(ctx)
+}
+
+func rangeOf(ctx antlr.ParserRuleContext) Range {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	return Range{
+		Start: Position{Line: start.GetLine(), Column: start.GetColumn() + 1},
+		End:   Position{Line: stop.GetLine(), Column: stop.GetColumn() + len(stop.GetText())},
+	}
+}
+
+// Write prints an indented outline of s and its children.
+func Write(w io.Writer, s *Symbol) error {
+	return write(w, s, 0)
+}
+
+func write(w io.Writer, s *Symbol, depth int) error {
+	detail := s.Name
+	if s.Kind == Method || s.Kind == Constructor {
+		params := []string{}
+		for _, p := range s.Parameters {
+			params = append(params, p.Type+" "+p.Name)
+		}
+		detail += "(" + strings.Join(params, ", ") + ")"
+	}
+	if s.Type != "" {
+		detail += ": " + s.Type
+	}
+	_, err := fmt.Fprintf(w, "%s%s %s %d:%d-%d:%d\n", strings.Repeat("\t", depth), s.Kind, detail,
+		s.Range.Start.Line, s.Range.Start.Column, s.Range.End.Line, s.Range.End.Column)
+	if err != nil {
+		return err
+	}
+	for _, c := range s.Children {
+		if err := write(w, c, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package symbols
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `public with sharing class Foo {
+	@TestVisible
+	private static Integer count, total;
+	@AuraEnabled(cacheable=true)
+	public static List<Account> find(final String name, Map<Id, String> labels) {
+		return null;
+	}
+	public enum Color { RED }
+}`
+	s, err := Parse("", strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.Kind != Class || s.Name != "Foo" || !reflect.DeepEqual(s.Modifiers, []string{"public", "with sharing"}) {
+		t.Errorf("unexpected class symbol: %#v", s)
+	}
+	if s.Range != (Range{Start: Position{1, 1}, End: Position{9, 1}}) {
+		t.Errorf("unexpected class range: %#v", s.Range)
+	}
+	if len(s.Children) != 4 {
+		t.Fatalf("expected 4 children, got %d", len(s.Children))
+	}
+	if total := s.Children[1]; total.Name != "total" || total.Type != "Integer" || !reflect.DeepEqual(total.Annotations, []string{"@TestVisible"}) {
+		t.Errorf("unexpected field symbol: %#v", total)
+	}
+	find := s.Children[2]
+	expected := &Symbol{
+		Kind:        Method,
+		Name:        "find",
+		Modifiers:   []string{"public", "static"},
+		Annotations: []string{"@AuraEnabled(cacheable=true)"},
+		Type:        "List<Account>",
+		Parameters: []Parameter{
+			{Name: "name", Type: "String", Modifiers: []string{"final"}},
+			{Name: "labels", Type: "Map<Id, String>"},
+		},
+		Range: Range{Start: Position{4, 2}, End: Position{7, 2}},
+	}
+	if !reflect.DeepEqual(find, expected) {
+		t.Errorf("unexpected method symbol.  expected:\n%#v\ngot:\n%#v", expected, find)
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, s); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	outline := `class Foo 1:1-9:1
+	field count: Integer 2:2-3:37
+	field total: Integer 2:2-3:37
+	method find(String name, Map<Id, String> labels): List<Account> 4:2-7:2
+	enum Color 8:2-8:26
+		enumConstant RED 8:22-8:24
+`
+	if out.String() != outline {
+		t.Errorf("unexpected outline.  expected:\n%s\ngot:\n%s", outline, out.String())
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	s, err := Parse("", strings.NewReader(`trigger AccountTrigger on Account (before insert) {
+	AccountHandler.run();
+}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.Kind != Trigger || s.Name != "AccountTrigger" || s.Type != "Account" {
+		t.Errorf("unexpected trigger symbol: %#v", s)
+	}
+}